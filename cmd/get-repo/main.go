@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"get-repo/config"
 	"get-repo/internal/cli"
@@ -22,17 +25,24 @@ _get_repo_completion() {
     prev="${COMP_WORDS[COMP_CWORD-1]}"
     
     # Basic commands and options
-    opts="list update remove clone completion --help --version --interactive --force --file --cd"
+    opts="list update fetch status exec remove tag untag tags sync export clone path unshallow relocate providers completion --help --version --interactive --force --file --jobs --cd --pull --ff-only --rebase --autostash --dry-run --prune --manifest --refs --cached --refresh --dirty --behind --unpushed --json --prefix --host --owner --root --not --all --ref --depth --filter --single-branch --sparse"
     
     case "${prev}" in
-        update|remove)
-            # Get repository list for update/remove commands
+        list|update|fetch|status|exec|remove|unshallow|export|--not)
+            # Get repository list for update/remove commands, and @groups
             if command -v get-repo >/dev/null 2>&1; then
-                repo_list=$(get-repo list 2>/dev/null)
+                repo_list="$(get-repo list --cached 2>/dev/null | cut -f1) $(get-repo tags 2>/dev/null | cut -f1)"
                 COMPREPLY=($(compgen -W "${repo_list}" -- ${cur}))
                 return 0
             fi
             ;;
+        tag|untag)
+            # Existing tag names, without the "@"
+            if command -v get-repo >/dev/null 2>&1; then
+                COMPREPLY=($(compgen -W "$(get-repo tags 2>/dev/null | cut -f1 | cut -c2-)" -- ${cur}))
+                return 0
+            fi
+            ;;
         completion)
             COMPREPLY=($(compgen -W "bash zsh fish" -- ${cur}))
             return 0
@@ -42,6 +52,14 @@ _get_repo_completion() {
             COMPREPLY=($(compgen -f -- ${cur}))
             return 0
             ;;
+        --filter)
+            COMPREPLY=($(compgen -W "blob:none tree:0" -- ${cur}))
+            return 0
+            ;;
+        --jobs|-j|--ref|--depth|--sparse|--host|--owner|--root)
+            # Free-form value
+            return 0
+            ;;
         get-repo)
             # Complete with commands, URLs, or repository names
             COMPREPLY=($(compgen -W "${opts}" -- ${cur}))
@@ -52,10 +70,10 @@ _get_repo_completion() {
                 return 0
             fi
             
-            # Add fuzzy matching hints
-            if [[ ${cur} == g* ]] || [[ ${cur} == b* ]]; then
-                local fuzzy_patterns="gh: gl: git: gitl: gitlab: github: bb: bit: bitbucket:"
-                COMPREPLY=($(compgen -W "${fuzzy_patterns}" -- ${cur}))
+            # Add shorthand provider prefixes (built-in and configured)
+            if [[ ${cur} != *:* ]] && command -v get-repo >/dev/null 2>&1; then
+                local fuzzy_patterns=$(get-repo providers 2>/dev/null | cut -f1)
+                COMPREPLY+=($(compgen -W "${fuzzy_patterns}" -- ${cur}))
             fi
             
             return 0
@@ -80,19 +98,66 @@ _get_repo() {
         '(-v --version)'{-v,--version}'[Show version information]' \
         '(-i --interactive)'{-i,--interactive}'[Force interactive TUI mode]' \
         '(-f --file)'{-f,--file}'[Read repository URLs from file]:file:_files' \
+        '(-j --jobs)'{-j,--jobs}'[Max concurrent git operations]:jobs:' \
         '--force[Skip confirmation prompts]' \
         '--cd[Output repository path after clone/update]' \
+        '--pull[Fast-forward repositories that are already cloned]' \
+        '--ff-only[Update by fast-forwarding only]' \
+        '--rebase[Update by rebasing local commits onto upstream]' \
+        '--autostash[Rebase, stashing uncommitted changes around it]' \
+        '--dry-run[Show what relocate would move]' \
+        '--prune[Remove repositories not in the sync manifest]' \
+        '--dirty[Only repositories with local changes]' \
+        '--behind[Only repositories behind their upstream]' \
+        '--unpushed[Only repositories with unpushed commits]' \
+        '--json[Print status or export as JSON]' \
+        '--manifest[Export a YAML manifest]' \
+        '--refs[Record checked out branches in the export]' \
+        '--cached[List from the repository index without checking the disk]' \
+        '--refresh[Rescan the disk and rebuild the repository index]' \
+        '--prefix[Prefix exec output with the repository name]' \
+        '*--host[Only repositories on matching hosts]:host:' \
+        '*--owner[Only repositories of matching owners]:owner:' \
+        '*--root[Only repositories in a root, or where to clone]:root:' \
+        '*--not[Exclude repositories matching a pattern]:pattern:' \
+        '--all[Select every repository]' \
+        '--ref[Clone at a branch, tag or commit]:ref:' \
+        '--depth[Shallow clone with the last n commits]:depth:' \
+        '--filter[Partial clone filter]:filter:(blob\:none tree\:0)' \
+        '--single-branch[Only fetch the checked out branch]' \
+        '--sparse[Sparse checkout of comma-separated directories]:dirs:' \
         '*::command:_get_repo_command'
 }
 
+_get_repo_providers() {
+    local -a fuzzy_patterns
+    if (( $+commands[get-repo] )); then
+        # "prefix:<TAB>host" lines; escape the colon for _describe
+        fuzzy_patterns=(${(f)"$(get-repo providers 2>/dev/null | awk -F'\t' '{sub(/:$/, "\\:", $1); print $1 ":" $2 " repository"}')"})
+        _describe -t fuzzy-patterns 'repository shorthand' fuzzy_patterns -S ''
+    fi
+}
+
 _get_repo_command() {
     local commands repos
     
     commands=(
         'list:List all repositories'
         'update:Update repositories'
+        'fetch:Fetch repositories and show ahead/behind counts'
+        'status:Show the state of all repositories'
+        'exec:Run a command in repositories'
         'remove:Remove repositories'
+        'tag:Tag repositories'
+        'untag:Remove a tag from repositories'
+        'tags:List tags and groups'
+        'sync:Clone and update repositories listed in a manifest'
+        'export:Write the remotes of repositories as a URL list or manifest'
         'clone:Clone repositories'
+        'path:Print the local checkout of a URL'
+        'unshallow:Convert shallow or partial clones to full'
+        'relocate:Move checkouts to match the configured layout'
+        'providers:List shorthand prefixes and their hosts'
         'completion:Generate shell completion scripts'
     )
     
@@ -101,35 +166,33 @@ _get_repo_command() {
         _describe -t commands 'command' commands
         _urls
         
-        # Add fuzzy matching patterns
-        local fuzzy_patterns=(
-            'gh:GitHub repository (e.g., gh:user/repo)'
-            'gl:GitLab repository (e.g., gl:user/repo)'
-            'bb:Bitbucket repository (e.g., bb:user/repo)'
-            'github:GitHub repository'
-            'gitlab:GitLab repository'
-            'bitbucket:Bitbucket repository'
-        )
-        _describe -t fuzzy-patterns 'repository shorthand' fuzzy_patterns
+        # Add shorthand provider prefixes (built-in and configured)
+        _get_repo_providers
     elif (( CURRENT >= 2 )); then
         case "$words[1]" in
-            update|remove)
+            list|update|fetch|status|exec|remove|unshallow|export)
                 # Get repository list
                 if (( $+commands[get-repo] )); then
-                    repos=(${(f)"$(get-repo list 2>/dev/null)"})
+                    repos=(${(f)"$(get-repo list --cached 2>/dev/null | cut -f1)"} ${(f)"$(get-repo tags 2>/dev/null | cut -f1)"})
                     _describe -t repositories 'repository' repos
                 fi
                 ;;
+            tag|untag)
+                if (( $+commands[get-repo] )); then
+                    if (( CURRENT == 2 )); then
+                        repos=(${(f)"$(get-repo tags 2>/dev/null | cut -f1 | cut -c2-)"})
+                        _describe -t tags 'tag' repos
+                    else
+                        repos=(${(f)"$(get-repo list --cached 2>/dev/null | cut -f1)"})
+                        _describe -t repositories 'repository' repos
+                    fi
+                fi
+                ;;
             clone)
                 # Multiple URLs can be provided
                 _urls
-                # Add fuzzy matching patterns for clone
-                local fuzzy_patterns=(
-                    'gh:GitHub repository'
-                    'gl:GitLab repository'
-                    'bb:Bitbucket repository'
-                )
-                _describe -t fuzzy-patterns 'repository shorthand' fuzzy_patterns
+                # Add shorthand provider prefixes for clone
+                _get_repo_providers
                 ;;
             completion)
                 local shells=(bash zsh fish)
@@ -153,18 +216,41 @@ complete -c get-repo -s h -l help -d "Show help message"
 complete -c get-repo -s v -l version -d "Show version information"
 complete -c get-repo -s i -l interactive -d "Force interactive TUI mode"
 complete -c get-repo -s f -l file -r -d "Read repository URLs from file"
+complete -c get-repo -s j -l jobs -x -d "Max concurrent git operations"
 complete -c get-repo -l force -d "Skip confirmation prompts"
 complete -c get-repo -l cd -d "Output repository path after clone/update"
+complete -c get-repo -l ref -x -d "Clone at a branch, tag or commit"
+complete -c get-repo -l depth -x -d "Shallow clone with the last n commits"
+complete -c get-repo -l filter -x -a "blob:none tree:0" -d "Partial clone filter"
+complete -c get-repo -l single-branch -d "Only fetch the checked out branch"
+complete -c get-repo -l sparse -x -d "Sparse checkout of comma-separated directories"
+complete -c get-repo -l pull -d "Fast-forward repositories that are already cloned"
+complete -c get-repo -l ff-only -d "Update by fast-forwarding only"
+complete -c get-repo -l rebase -d "Update by rebasing local commits onto upstream"
+complete -c get-repo -l autostash -d "Rebase, stashing uncommitted changes around it"
 
 # Subcommands
 complete -c get-repo -n "__fish_use_subcommand" -a "list" -d "List all repositories"
 complete -c get-repo -n "__fish_use_subcommand" -a "update" -d "Update repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "fetch" -d "Fetch repositories and show ahead/behind counts"
+complete -c get-repo -n "__fish_use_subcommand" -a "status" -d "Show the state of all repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "exec" -d "Run a command in repositories"
 complete -c get-repo -n "__fish_use_subcommand" -a "remove" -d "Remove repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "tag" -d "Tag repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "untag" -d "Remove a tag from repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "tags" -d "List tags and groups"
+complete -c get-repo -n "__fish_use_subcommand" -a "sync" -d "Clone and update repositories listed in a manifest"
+complete -c get-repo -n "__fish_use_subcommand" -a "export" -d "Write the remotes of repositories as a URL list or manifest"
 complete -c get-repo -n "__fish_use_subcommand" -a "clone" -d "Clone repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "path" -d "Print the local checkout of a URL"
+complete -c get-repo -n "__fish_use_subcommand" -a "unshallow" -d "Convert shallow or partial clones to full"
+complete -c get-repo -n "__fish_use_subcommand" -a "relocate" -d "Move checkouts to match the configured layout"
+complete -c get-repo -n "__fish_use_subcommand" -a "providers" -d "List shorthand prefixes and their hosts"
 complete -c get-repo -n "__fish_use_subcommand" -a "completion" -d "Generate shell completion scripts"
 
 # Repository completion for update and remove
-complete -c get-repo -n "__fish_seen_subcommand_from update remove" -a "(get-repo list 2>/dev/null)" -d "Repository"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove unshallow tag untag export" -a "(get-repo list --cached 2>/dev/null)" -d "Repository"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove export" -a "(get-repo tags 2>/dev/null)" -d "Group"
 
 # Shell completion for completion command
 complete -c get-repo -n "__fish_seen_subcommand_from completion" -a "bash zsh fish" -d "Shell"
@@ -172,15 +258,40 @@ complete -c get-repo -n "__fish_seen_subcommand_from completion" -a "bash zsh fi
 # Force flag for remove command
 complete -c get-repo -n "__fish_seen_subcommand_from remove" -l force -d "Skip confirmation prompts"
 
-# Fuzzy matching patterns
-complete -c get-repo -n "__fish_use_subcommand" -a "gh:" -d "GitHub repository (e.g., gh:user/repo)"
-complete -c get-repo -n "__fish_use_subcommand" -a "gl:" -d "GitLab repository (e.g., gl:user/repo)"
-complete -c get-repo -n "__fish_use_subcommand" -a "bb:" -d "Bitbucket repository (e.g., bb:user/repo)"
+# Index use for list command
+complete -c get-repo -n "__fish_seen_subcommand_from list" -l cached -d "List from the repository index without checking the disk"
+complete -c get-repo -n "__fish_seen_subcommand_from list" -l refresh -d "Rescan the disk and rebuild the repository index"
+
+# Filters and output format for status command
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l dirty -d "Only repositories with local changes"
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l behind -d "Only repositories behind their upstream"
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l unpushed -d "Only repositories with unpushed commits"
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l json -d "Print JSON instead of a table"
+
+# Selectors for commands working on repositories
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l host -x -d "Only repositories on matching hosts"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l owner -x -d "Only repositories of matching owners"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export sync" -l root -x -d "Only repositories in a root, or where sync clones"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l not -x -a "(get-repo list --cached 2>/dev/null)" -d "Exclude matching repositories"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l all -d "Select every repository"
+
+# Output mode for exec command
+complete -c get-repo -n "__fish_seen_subcommand_from exec" -l prefix -d "Prefix output with the repository name"
+
+# Output format for export command
+complete -c get-repo -n "__fish_seen_subcommand_from export" -l manifest -d "Write a YAML manifest"
+complete -c get-repo -n "__fish_seen_subcommand_from export" -l json -d "Write a JSON manifest"
+complete -c get-repo -n "__fish_seen_subcommand_from export" -l refs -d "Record checked out branches"
 
-# Fuzzy patterns for clone command
-complete -c get-repo -n "__fish_seen_subcommand_from clone" -a "gh:" -d "GitHub repository"
-complete -c get-repo -n "__fish_seen_subcommand_from clone" -a "gl:" -d "GitLab repository"
-complete -c get-repo -n "__fish_seen_subcommand_from clone" -a "bb:" -d "Bitbucket repository"`
+# Pruning for sync command
+complete -c get-repo -n "__fish_seen_subcommand_from sync" -l prune -d "Remove repositories not in the manifest"
+
+# Dry run for relocate command
+complete -c get-repo -n "__fish_seen_subcommand_from relocate" -l dry-run -d "Show what would be moved"
+
+# Shorthand provider prefixes (built-in and configured); "prefix:<TAB>host" lines
+complete -c get-repo -n "__fish_use_subcommand" -a "(get-repo providers 2>/dev/null)"
+complete -c get-repo -n "__fish_seen_subcommand_from clone" -a "(get-repo providers 2>/dev/null)"`
 
 func main() {
 	defer debug.LogFunction("main")()
@@ -200,6 +311,11 @@ func main() {
 	// Handle help and version
 	switch cmd.Type {
 	case cli.CommandHelp:
+		// Configured providers are listed in the help; a broken or missing
+		// config should not prevent showing it
+		if cfg, err := config.Load(); err == nil {
+			_ = cli.ApplyConfig(cfg)
+		}
 		fmt.Println(cli.GetHelpText())
 		return
 	case cli.CommandVersion:
@@ -223,6 +339,17 @@ func main() {
 	}
 	debug.Log("Configuration loaded: CodebasesPath=%s", cfg.CodebasesPath)
 
+	if err := cli.ApplyConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Provider listing only needs the configuration (used by completions)
+	if cmd.Type == cli.CommandProviders {
+		cli.NewRunner(cfg).Providers()
+		return
+	}
+
 	// Check if we need setup
 	if cfg.CodebasesPath == "" && cmd.Type != cli.CommandNone && cmd.Type != cli.CommandInteractive {
 		fmt.Fprintln(os.Stderr, "Error: VCS_CODEBASES path not set.")
@@ -237,54 +364,97 @@ func main() {
 		return
 	}
 
+	// Command line flags override configured defaults
+	if cmd.Jobs > 0 {
+		cfg.Jobs = cmd.Jobs
+	}
+	if cmd.Strategy != "" {
+		cfg.UpdateStrategy = cmd.Strategy
+	}
+
 	// Handle non-interactive commands
 	runner := cli.NewRunner(cfg)
+	runner.SetPullExisting(cmd.Flags["pull"])
+	if cmd.Type == cli.CommandClone || cmd.Type == cli.CommandSync {
+		// Elsewhere --root selects repositories; here it picks where they go
+		if len(cmd.Select.Roots) > 1 {
+			fmt.Fprintln(os.Stderr, "Error: --root can only be given once when cloning")
+			os.Exit(1)
+		}
+		if len(cmd.Select.Roots) == 1 {
+			if err := runner.SetRoot(cmd.Select.Roots[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+	if cmd.Flags["cd"] {
+		// Keep stdout for the path so "cd $(get-repo ... --cd)" works
+		runner.SetOutput(os.Stderr)
+	}
+
+	// Interrupting stops outstanding git operations cleanly; a second
+	// interrupt falls through to the default handler and exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
 	switch cmd.Type {
 	case cli.CommandList:
-		if err := runner.List(); err != nil {
+		mode := cli.ListVerified
+		if cmd.Flags["refresh"] {
+			mode = cli.ListRefresh
+		} else if cmd.Flags["cached"] {
+			mode = cli.ListCached
+		}
+		if err := runner.List(cmd.Select, mode); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
 	case cli.CommandClone:
 		// Handle bulk clone
-		var urls []string
+		var targets []cli.CloneTarget
 
 		// Check if we have a file to read from
 		if cmd.CloneFile != "" {
-			fileURLs, err := runner.ParseCloneFile(cmd.CloneFile)
+			fileTargets, err := runner.ParseCloneFile(cmd.CloneFile, cmd.Clone)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 				os.Exit(1)
 			}
-			urls = append(urls, fileURLs...)
+			targets = append(targets, fileTargets...)
 		}
 
 		// Add any additional URLs from command line
-		urls = append(urls, cmd.CloneURLs...)
+		for _, url := range cmd.CloneURLs {
+			targets = append(targets, cli.CloneTarget{URL: url, Options: cmd.Clone})
+		}
 
 		// If no URLs collected, fall back to single URL for backward compatibility
-		if len(urls) == 0 && cmd.URLToClone != "" {
-			urls = append(urls, cmd.URLToClone)
+		if len(targets) == 0 && cmd.URLToClone != "" {
+			targets = append(targets, cli.CloneTarget{URL: cmd.URLToClone, Options: cmd.Clone})
 		}
 
-		if len(urls) == 0 {
+		if len(targets) == 0 {
 			fmt.Fprintln(os.Stderr, "Error: No URLs specified")
 			os.Exit(1)
 		}
 
 		// Clone single or multiple repositories
 		var clonedPath string
-		if len(urls) == 1 {
-			path, err := runner.Clone(urls[0])
+		if len(targets) == 1 {
+			path, err := runner.Clone(ctx, targets[0].URL, targets[0].Options)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 			clonedPath = path
 		} else {
-			if err := runner.CloneMultiple(urls); err != nil {
+			if err := runner.CloneMultiple(ctx, targets); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
@@ -297,12 +467,12 @@ func main() {
 		}
 
 	case cli.CommandUpdate:
-		path, err := runner.Update(cmd.Args)
+		path, err := runner.Update(ctx, cmd.Select)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		
+
 		// If --cd flag is set and we updated a single repo, output the path
 		if cmd.Flags["cd"] && path != "" {
 			fmt.Println(path)
@@ -310,7 +480,86 @@ func main() {
 
 	case cli.CommandRemove:
 		force := cmd.Flags["force"]
-		if err := runner.Remove(cmd.Args, force); err != nil {
+		if err := runner.Remove(cmd.Select, force); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandPath:
+		path, err := runner.Path(cmd.Args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(path)
+
+	case cli.CommandFetch:
+		if err := runner.Fetch(ctx, cmd.Select); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandStatus:
+		filter := cli.StatusFilter{
+			Dirty:    cmd.Flags["dirty"],
+			Behind:   cmd.Flags["behind"],
+			Unpushed: cmd.Flags["unpushed"],
+		}
+		if err := runner.Status(ctx, cmd.Select, filter, cmd.Flags["json"]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandExec:
+		if err := runner.Exec(ctx, cmd.Select, cmd.Exec, cmd.Flags["prefix"]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandTag:
+		if err := runner.Tag(cmd.Args[0], cmd.Select); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandUntag:
+		if err := runner.Untag(cmd.Args[0], cmd.Select); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandTags:
+		if err := runner.ListTags(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandSync:
+		if err := runner.Sync(ctx, cmd.ManifestFile, cmd.Flags["prune"], cmd.Flags["force"]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandExport:
+		format := cli.ExportFormatFor(cmd.OutputFile)
+		if cmd.Flags["manifest"] {
+			format = cli.ExportManifest
+		} else if cmd.Flags["json"] {
+			format = cli.ExportJSON
+		}
+		if err := runner.Export(ctx, cmd.Select, format, cmd.Flags["refs"], cmd.OutputFile, cmd.Flags["force"]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandUnshallow:
+		if err := runner.Unshallow(ctx, cmd.Args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case cli.CommandRelocate:
+		if err := runner.Relocate(ctx, cmd.Flags["dry-run"]); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -7,17 +7,41 @@ _get_repo_completion() {
     prev="${COMP_WORDS[COMP_CWORD-1]}"
     
     # Basic commands and options
-    opts="list update remove --help --version --interactive --force"
+    opts="list update fetch status exec remove tag untag tags sync export clone path unshallow relocate providers completion --help --version --interactive --force --file --jobs --cd --pull --ff-only --rebase --autostash --dry-run --prune --manifest --refs --cached --refresh --dirty --behind --unpushed --json --prefix --host --owner --root --not --all --ref --depth --filter --single-branch --sparse"
     
     case "${prev}" in
-        update|remove)
-            # Get repository list for update/remove commands
+        list|update|fetch|status|exec|remove|unshallow|export|--not)
+            # Get repository list for update/remove commands, and @groups
             if command -v get-repo >/dev/null 2>&1; then
-                repo_list=$(get-repo list 2>/dev/null)
+                repo_list="$(get-repo list --cached 2>/dev/null | cut -f1) $(get-repo tags 2>/dev/null | cut -f1)"
                 COMPREPLY=($(compgen -W "${repo_list}" -- ${cur}))
                 return 0
             fi
             ;;
+        tag|untag)
+            # Existing tag names, without the "@"
+            if command -v get-repo >/dev/null 2>&1; then
+                COMPREPLY=($(compgen -W "$(get-repo tags 2>/dev/null | cut -f1 | cut -c2-)" -- ${cur}))
+                return 0
+            fi
+            ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- ${cur}))
+            return 0
+            ;;
+        --file|-f)
+            # File completion
+            COMPREPLY=($(compgen -f -- ${cur}))
+            return 0
+            ;;
+        --filter)
+            COMPREPLY=($(compgen -W "blob:none tree:0" -- ${cur}))
+            return 0
+            ;;
+        --jobs|-j|--ref|--depth|--sparse|--host|--owner|--root)
+            # Free-form value
+            return 0
+            ;;
         get-repo)
             # Complete with commands, URLs, or repository names
             COMPREPLY=($(compgen -W "${opts}" -- ${cur}))
@@ -28,6 +52,12 @@ _get_repo_completion() {
                 return 0
             fi
             
+            # Add shorthand provider prefixes (built-in and configured)
+            if [[ ${cur} != *:* ]] && command -v get-repo >/dev/null 2>&1; then
+                local fuzzy_patterns=$(get-repo providers 2>/dev/null | cut -f1)
+                COMPREPLY+=($(compgen -W "${fuzzy_patterns}" -- ${cur}))
+            fi
+            
             return 0
             ;;
     esac
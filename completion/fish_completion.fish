@@ -5,15 +5,80 @@ complete -c get-repo -f
 complete -c get-repo -s h -l help -d "Show help message"
 complete -c get-repo -s v -l version -d "Show version information"
 complete -c get-repo -s i -l interactive -d "Force interactive TUI mode"
+complete -c get-repo -s f -l file -r -d "Read repository URLs from file"
+complete -c get-repo -s j -l jobs -x -d "Max concurrent git operations"
 complete -c get-repo -l force -d "Skip confirmation prompts"
+complete -c get-repo -l cd -d "Output repository path after clone/update"
+complete -c get-repo -l ref -x -d "Clone at a branch, tag or commit"
+complete -c get-repo -l depth -x -d "Shallow clone with the last n commits"
+complete -c get-repo -l filter -x -a "blob:none tree:0" -d "Partial clone filter"
+complete -c get-repo -l single-branch -d "Only fetch the checked out branch"
+complete -c get-repo -l sparse -x -d "Sparse checkout of comma-separated directories"
+complete -c get-repo -l pull -d "Fast-forward repositories that are already cloned"
+complete -c get-repo -l ff-only -d "Update by fast-forwarding only"
+complete -c get-repo -l rebase -d "Update by rebasing local commits onto upstream"
+complete -c get-repo -l autostash -d "Rebase, stashing uncommitted changes around it"
 
 # Subcommands
 complete -c get-repo -n "__fish_use_subcommand" -a "list" -d "List all repositories"
 complete -c get-repo -n "__fish_use_subcommand" -a "update" -d "Update repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "fetch" -d "Fetch repositories and show ahead/behind counts"
+complete -c get-repo -n "__fish_use_subcommand" -a "status" -d "Show the state of all repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "exec" -d "Run a command in repositories"
 complete -c get-repo -n "__fish_use_subcommand" -a "remove" -d "Remove repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "tag" -d "Tag repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "untag" -d "Remove a tag from repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "tags" -d "List tags and groups"
+complete -c get-repo -n "__fish_use_subcommand" -a "sync" -d "Clone and update repositories listed in a manifest"
+complete -c get-repo -n "__fish_use_subcommand" -a "export" -d "Write the remotes of repositories as a URL list or manifest"
+complete -c get-repo -n "__fish_use_subcommand" -a "clone" -d "Clone repositories"
+complete -c get-repo -n "__fish_use_subcommand" -a "path" -d "Print the local checkout of a URL"
+complete -c get-repo -n "__fish_use_subcommand" -a "unshallow" -d "Convert shallow or partial clones to full"
+complete -c get-repo -n "__fish_use_subcommand" -a "relocate" -d "Move checkouts to match the configured layout"
+complete -c get-repo -n "__fish_use_subcommand" -a "providers" -d "List shorthand prefixes and their hosts"
+complete -c get-repo -n "__fish_use_subcommand" -a "completion" -d "Generate shell completion scripts"
 
 # Repository completion for update and remove
-complete -c get-repo -n "__fish_seen_subcommand_from update remove" -a "(get-repo list 2>/dev/null)" -d "Repository"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove unshallow tag untag export" -a "(get-repo list --cached 2>/dev/null)" -d "Repository"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove export" -a "(get-repo tags 2>/dev/null)" -d "Group"
+
+# Shell completion for completion command
+complete -c get-repo -n "__fish_seen_subcommand_from completion" -a "bash zsh fish" -d "Shell"
 
 # Force flag for remove command
-complete -c get-repo -n "__fish_seen_subcommand_from remove" -l force -d "Skip confirmation prompts"
\ No newline at end of file
+complete -c get-repo -n "__fish_seen_subcommand_from remove" -l force -d "Skip confirmation prompts"
+
+# Index use for list command
+complete -c get-repo -n "__fish_seen_subcommand_from list" -l cached -d "List from the repository index without checking the disk"
+complete -c get-repo -n "__fish_seen_subcommand_from list" -l refresh -d "Rescan the disk and rebuild the repository index"
+
+# Filters and output format for status command
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l dirty -d "Only repositories with local changes"
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l behind -d "Only repositories behind their upstream"
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l unpushed -d "Only repositories with unpushed commits"
+complete -c get-repo -n "__fish_seen_subcommand_from status" -l json -d "Print JSON instead of a table"
+
+# Selectors for commands working on repositories
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l host -x -d "Only repositories on matching hosts"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l owner -x -d "Only repositories of matching owners"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export sync" -l root -x -d "Only repositories in a root, or where sync clones"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l not -x -a "(get-repo list --cached 2>/dev/null)" -d "Exclude matching repositories"
+complete -c get-repo -n "__fish_seen_subcommand_from list update fetch status exec remove tag untag export" -l all -d "Select every repository"
+
+# Output mode for exec command
+complete -c get-repo -n "__fish_seen_subcommand_from exec" -l prefix -d "Prefix output with the repository name"
+
+# Output format for export command
+complete -c get-repo -n "__fish_seen_subcommand_from export" -l manifest -d "Write a YAML manifest"
+complete -c get-repo -n "__fish_seen_subcommand_from export" -l json -d "Write a JSON manifest"
+complete -c get-repo -n "__fish_seen_subcommand_from export" -l refs -d "Record checked out branches"
+
+# Pruning for sync command
+complete -c get-repo -n "__fish_seen_subcommand_from sync" -l prune -d "Remove repositories not in the manifest"
+
+# Dry run for relocate command
+complete -c get-repo -n "__fish_seen_subcommand_from relocate" -l dry-run -d "Show what would be moved"
+
+# Shorthand provider prefixes (built-in and configured); "prefix:<TAB>host" lines
+complete -c get-repo -n "__fish_use_subcommand" -a "(get-repo providers 2>/dev/null)"
+complete -c get-repo -n "__fish_seen_subcommand_from clone" -a "(get-repo providers 2>/dev/null)"
\ No newline at end of file
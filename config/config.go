@@ -5,18 +5,132 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const (
 	AppName        = "get-repo"
 	ConfigFileName = "config.json"
 	EnvConfigPath  = "GET_REPO_CONFIG"
+	EnvCacheDir    = "GET_REPO_CACHE"
 )
 
 // Config holds the application's configuration.
 type Config struct {
-	CodebasesPath string `json:"codebases_path"`
-	ConfigPath    string `json:"-"` // Path where this config was loaded from
+	CodebasesPath  string              `json:"codebases_path"`
+	Timeouts       Timeouts            `json:"timeouts,omitempty"`
+	Jobs           int                 `json:"jobs,omitempty"`      // Max concurrent git operations
+	HostJobs       map[string]int      `json:"host_jobs,omitempty"` // Per-host caps, "*" for any host
+	Providers      []Provider          `json:"providers,omitempty"`
+	URLRewrites    []URLRewrite        `json:"url_rewrites,omitempty"`
+	Layout         Layout              `json:"layout,omitempty"`
+	CloneDefaults  []CloneDefault      `json:"clone_defaults,omitempty"`
+	Submodules     Submodules          `json:"submodules,omitempty"`
+	UpdateStrategy string              `json:"update_strategy,omitempty"` // "ff-only" (default), "rebase" or "autostash"
+	Commands       []SavedCommand      `json:"commands,omitempty"`        // Commands the TUI can run across repositories
+	Groups         map[string][]string `json:"groups,omitempty"`          // Named selectors usable as "@name", e.g. "mine": ["github.com/me"]
+	Scan           Scan                `json:"scan,omitempty"`            // Where to look for repositories
+	Roots          []Root              `json:"roots,omitempty"`           // More directories to keep repositories in, next to codebases_path
+	ConfigPath     string              `json:"-"`                         // Path where this config was loaded from
+}
+
+// Provider declares a shorthand prefix for a git host, such as
+// "corp:team/service" for a self-hosted GitLab.
+type Provider struct {
+	Name         string   `json:"name"`                    // Prefix, fuzzy matched (e.g. "corp")
+	Aliases      []string `json:"aliases,omitempty"`       // Extra exact prefixes (e.g. "cp")
+	Host         string   `json:"host"`                    // e.g. "git.corp.example"
+	Protocol     string   `json:"protocol,omitempty"`      // "https" (default) or "ssh"
+	DefaultOwner string   `json:"default_owner,omitempty"` // Used for "corp:repo"
+	Web          string   `json:"web,omitempty"`           // Browser link style: "github", "gitea", "bitbucket" or "gitlab"
+}
+
+// Layout controls the directory structure repositories are cloned into
+type Layout struct {
+	Template    string            `json:"template,omitempty"`     // e.g. "{{.Host}}/{{.Owner}}/{{.Repo}}"
+	Lowercase   bool              `json:"lowercase,omitempty"`    // Lowercase the whole path
+	HostAliases map[string]string `json:"host_aliases,omitempty"` // e.g. "github.com": "gh"
+}
+
+// CloneDefault sets clone options for repositories matching a pattern
+type CloneDefault struct {
+	Match        string   `json:"match"`                   // Host ("github.com") or host/path glob ("github.com/bigcorp/*")
+	Depth        int      `json:"depth,omitempty"`         // Shallow clone depth
+	Filter       string   `json:"filter,omitempty"`        // Partial clone filter, e.g. "blob:none" or "tree:0"
+	SingleBranch bool     `json:"single_branch,omitempty"` // Only fetch the checked out branch
+	Sparse       []string `json:"sparse,omitempty"`        // Directories for a sparse checkout
+}
+
+// Submodules controls whether submodules are initialized on clone and
+// updated after pulls
+type Submodules struct {
+	Disabled bool     `json:"disabled,omitempty"` // Never touch submodules
+	Exclude  []string `json:"exclude,omitempty"`  // Repositories to leave alone, e.g. "github.com/bigcorp/*"
+}
+
+// Scan limits how far the codebases directory is searched for repositories
+type Scan struct {
+	MaxDepth int      `json:"max_depth,omitempty"` // Directory levels to search, 0 for no limit
+	Ignore   []string `json:"ignore,omitempty"`    // Directories to skip, e.g. "archive" or "github.com/bigcorp/*"
+	Nested   bool     `json:"nested,omitempty"`    // Also list repositories inside other repositories' working trees
+}
+
+// Root is a named directory repositories are kept in besides the codebases
+// directory, e.g. work code on an encrypted volume
+type Root struct {
+	Name   string   `json:"name"`             // e.g. "work"; repository names in it start with "work/"
+	Path   string   `json:"path"`             // e.g. "/mnt/secure/work"
+	Layout Layout   `json:"layout,omitempty"` // Clone layout inside the root, defaults to the top-level layout
+	Match  []string `json:"match,omitempty"`  // Hosts or host/path globs cloned here, e.g. "git.corp.example" or "github.com/corp"
+}
+
+// SavedCommand is a shell command the TUI offers to run in the selected
+// repositories
+type SavedCommand struct {
+	Name    string `json:"name"`    // Shown in the TUI, e.g. "Prune branches"
+	Command string `json:"command"` // Run by the shell in each repository, e.g. "git gc --auto"
+}
+
+// URLRewrite changes the URL used to reach a remote without changing where it
+// is checked out. Set Host and Protocol to force HTTPS or SSH for a host, or
+// URL and InsteadOf to replace a URL prefix like git's url.<base>.insteadOf.
+type URLRewrite struct {
+	Host      string `json:"host,omitempty"`       // Host name or glob, e.g. "github.com"
+	Protocol  string `json:"protocol,omitempty"`   // "https" or "ssh"
+	URL       string `json:"url,omitempty"`        // e.g. "git@github.com:"
+	InsteadOf string `json:"instead_of,omitempty"` // e.g. "https://github.com/"
+}
+
+// Timeouts limits how long individual git operations may run.
+// Unset values fall back to the built-in defaults.
+type Timeouts struct {
+	Clone  Duration `json:"clone,omitempty"`
+	Pull   Duration `json:"pull,omitempty"`
+	Status Duration `json:"status,omitempty"`
+}
+
+// Duration is a time.Duration stored in JSON as a string such as "90s" or "10m"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a Go duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts a Go duration string such as "30s" or "5m"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+
+	*d = Duration(parsed)
+	return nil
 }
 
 // Load reads the configuration file and returns a Config struct.
@@ -128,3 +242,34 @@ func IsFirstRun() bool {
 	_, err = os.Stat(cfgPath)
 	return os.IsNotExist(err)
 }
+
+// DataPath returns the path of a file next to the config file, which holds
+// state edited through commands rather than by hand, such as tags.
+// Priority: 1. The directory of the config file in use, 2. The default config directory
+func DataPath(name string) (string, error) {
+	cfgPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	if cfgPath == "" {
+		if cfgPath, err = getDefaultConfigPath(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(filepath.Dir(cfgPath), name), nil
+}
+
+// CachePath returns the path of a file in the cache directory, which holds
+// state that can be rebuilt, such as fetch results.
+// Priority: 1. Environment variable, 2. The user cache directory
+func CachePath(name string) (string, error) {
+	if envDir := os.Getenv(EnvCacheDir); envDir != "" {
+		return filepath.Join(envDir, name), nil
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, AppName, name), nil
+}
@@ -2,171 +2,500 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"get-repo/config"
+	"get-repo/internal/jobs"
 	"get-repo/internal/repo"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
-	"sync"
+	"time"
 )
 
 // Runner handles non-interactive command execution
 type Runner struct {
-	config  config.Config
-	manager *repo.Manager
-	git     *repo.Git
+	config       config.Config
+	manager      *repo.Manager
+	git          *repo.Git
+	scheduler    *jobs.Scheduler
+	out          io.Writer           // Progress and result messages
+	pullExisting bool                // Fast-forward checkouts that are already cloned
+	strategy     repo.UpdateStrategy // How update integrates upstream changes
+	tracking     *repo.TrackingStore // Ahead/behind counts from the last fetch or update
+	root         string              // Root that clones go into, "" to follow the roots' match rules
 }
 
 // NewRunner creates a new command runner
 func NewRunner(cfg config.Config) *Runner {
+	git := repo.NewGit(cfg.CodebasesPath)
+	git.SetTimeouts(repo.Timeouts{
+		Clone:  time.Duration(cfg.Timeouts.Clone),
+		Pull:   time.Duration(cfg.Timeouts.Pull),
+		Status: time.Duration(cfg.Timeouts.Status),
+	})
+
+	// ApplyConfig has already rejected unknown strategies
+	strategy, _ := repo.ParseUpdateStrategy(cfg.UpdateStrategy)
+
+	// Without a cache directory tracking is only kept for this run
+	trackingPath, _ := config.CachePath(repo.TrackingFileName)
+
+	// Likewise, without a cache directory every command scans the disk
+	indexPath, _ := config.CachePath(repo.IndexFileName)
+	manager := repo.NewManager(cfg.CodebasesPath)
+	manager.OpenIndex(indexPath)
+
 	return &Runner{
-		config:  cfg,
-		manager: repo.NewManager(cfg.CodebasesPath),
-		git:     repo.NewGit(cfg.CodebasesPath),
+		config:    cfg,
+		manager:   manager,
+		git:       git,
+		scheduler: jobs.NewScheduler(cfg.Jobs, cfg.HostJobs),
+		out:       os.Stdout,
+		strategy:  strategy,
+		tracking:  repo.LoadTrackingStore(trackingPath),
 	}
 }
 
-// List lists all repositories
-func (r *Runner) List() error {
-	repos, err := r.manager.List()
+// SetOutput redirects progress and result messages, e.g. to stderr when
+// stdout is reserved for a path printed for --cd
+func (r *Runner) SetOutput(w io.Writer) {
+	r.out = w
+}
+
+// SetPullExisting makes clone fast-forward repositories that are already
+// cloned, instead of just reporting their path
+func (r *Runner) SetPullExisting(pull bool) {
+	r.pullExisting = pull
+}
+
+// SetRoot makes clone and sync put new checkouts into the named root,
+// instead of the one the roots' match rules pick
+func (r *Runner) SetRoot(name string) error {
+	if name != "" {
+		if err := repo.CheckRootNames([]string{name}); err != nil {
+			return err
+		}
+	}
+	r.root = name
+	return nil
+}
+
+// clonePath returns where the remote is checked out, in the root given to
+// SetRoot if any
+func (r *Runner) clonePath(remote repo.RemoteURL) (string, error) {
+	if r.root != "" {
+		return remote.ClonePathIn(r.root)
+	}
+	return remote.ClonePath(), nil
+}
+
+// ListMode tells List whether to trust the repository index
+type ListMode int
+
+const (
+	ListVerified ListMode = iota // Use the index unless the disk changed since it was built
+	ListCached                   // Use the index as it is, even if it may be stale
+	ListRefresh                  // Scan the disk and rebuild the index
+)
+
+// List lists all repositories and directories, or only the repositories
+// the selector picks
+func (r *Runner) List(sel repo.Selector, mode ListMode) error {
+	var repos []repo.Repository
+	var err error
+	switch mode {
+	case ListCached:
+		repos, err = r.manager.ListCached()
+	case ListRefresh:
+		repos, err = r.manager.Rescan()
+	default:
+		repos, err = r.manager.List()
+	}
 	if err != nil {
 		return fmt.Errorf("error scanning repositories: %w", err)
 	}
 
+	if !sel.IsEmpty() {
+		names, err := r.manager.Select(sel)
+		if err != nil {
+			return err
+		}
+		repos = make([]repo.Repository, len(names))
+		for i, name := range names {
+			repos[i] = repo.Repository{Name: name, IsGitDir: true}
+		}
+	}
+
 	if len(repos) == 0 {
-		fmt.Println("No repositories found.")
+		fmt.Fprintln(r.out, "No repositories found.")
 		return nil
 	}
 
 	for _, repo := range repos {
-		fmt.Println(repo.Name)
+		// Tab separated so completions can cut the badge off
+		if t, ok := r.tracking.Get(repo.Name); ok && repo.IsGitDir && t.Badge() != "" {
+			fmt.Fprintf(r.out, "%s\t%s\n", repo.Name, t.Badge())
+			continue
+		}
+		fmt.Fprintln(r.out, repo.Name)
 	}
 
 	return nil
 }
 
-// Clone clones a repository
-func (r *Runner) Clone(url string) (string, error) {
-	// Expand short notation
-	expandedURL := repo.ExpandShortNotation(url)
-	
-	// Validate URL
-	if err := repo.ValidateURL(expandedURL); err != nil {
+// Providers prints every shorthand prefix with its host, tab separated.
+// Shell completion scripts use this to offer configured providers.
+func (r *Runner) Providers() {
+	for _, p := range repo.Providers() {
+		for _, prefix := range p.Prefixes() {
+			fmt.Fprintf(r.out, "%s:\t%s\n", prefix, p.Host)
+		}
+	}
+}
+
+// Clone clones a repository. opts take precedence over configured clone
+// defaults, and a ref suffix on the URL ("@v1.2", "#branch") over opts.Ref.
+// Cancelling ctx stops the clone and removes the partial checkout.
+func (r *Runner) Clone(ctx context.Context, url string, opts repo.CloneOptions) (string, error) {
+	// Expand short notation and validate URL. Rewrite rules only change the
+	// URL git talks to, never the clone path.
+	remote, err := repo.ResolveURL(url)
+	if err != nil {
 		return "", fmt.Errorf("invalid URL: %w", err)
 	}
+	expandedURL := remote.CloneURL()
 
 	// Get destination path
-	clonePath := repo.GetClonePath(expandedURL)
+	clonePath, err := r.clonePath(remote)
+	if err != nil {
+		return "", err
+	}
 	destination := r.manager.GetFullPath(clonePath)
 
-	// Check if already exists
-	if r.manager.PathExists(clonePath) {
-		return "", fmt.Errorf("repository already exists at %s", clonePath)
+	// An existing clone of the same remote is reused, so running the same
+	// command twice succeeds; a requested ref is checked out in it
+	exists, err := r.existingCheckout(ctx, remote, clonePath)
+	if err != nil {
+		return "", err
+	}
+	// A browser link's ref ends where the remote's branch names say
+	if exists {
+		remote = r.git.ResolveLinkRefIn(ctx, destination, remote)
+	} else if remote, err = r.git.ResolveLinkRef(ctx, remote); err != nil {
+		return "", fmt.Errorf("failed to look up the ref of %s: %w", url, err)
 	}
+	ref := remote.RequestedRef(opts)
+	opts = remote.ResolveOptions(opts)
 
-	fmt.Printf("Cloning %s into %s...\n", expandedURL, clonePath)
+	if exists {
+		if ref != "" {
+			if err := r.checkoutRef(ctx, clonePath, ref); err != nil {
+				return "", err
+			}
+		} else if r.pullExisting {
+			if err := r.fastForward(ctx, clonePath); err != nil {
+				return "", err
+			}
+		} else {
+			fmt.Fprintf(r.out, "Already cloned at %s\n", clonePath)
+		}
+		return linkedPath(destination, remote.Subpath), nil
+	}
+
+	if desc := opts.String(); desc != "" {
+		fmt.Fprintf(r.out, "Cloning %s into %s (%s)...\n", expandedURL, clonePath, desc)
+	} else {
+		fmt.Fprintf(r.out, "Cloning %s into %s...\n", expandedURL, clonePath)
+	}
 
 	// Perform clone
-	result := r.git.Clone(expandedURL, destination)
+	display := newProgressDisplay([]string{clonePath})
+	display.Set(0, "starting")
+	result := r.git.Clone(ctx, expandedURL, destination, opts, display.Progress(0))
+	display.Finish(0, statusText(result))
+	display.Close()
 	if !result.Success {
 		return "", fmt.Errorf("clone failed: %w", result.Error)
 	}
+	r.manager.RecordFetch(clonePath)
+	r.saveIndex()
+	if result.SubmoduleError != nil {
+		return "", fmt.Errorf("cloned into %s, but %w", clonePath, result.SubmoduleError)
+	}
 
-	fmt.Println("Clone completed successfully.")
-	return destination, nil
+	fmt.Fprintln(r.out, "Clone completed successfully.")
+	return linkedPath(destination, remote.Subpath), nil
 }
 
-// Update updates one or more repositories
-func (r *Runner) Update(repoNames []string) (string, error) {
-	if len(repoNames) == 0 {
+// Path resolves a URL, shorthand or repository name to its local checkout
+// without contacting any remote
+func (r *Runner) Path(target string) (string, error) {
+	remote, err := repo.ResolveURL(target)
+	if err == nil {
+		clonePath := remote.ClonePath()
+		candidates := []string{clonePath}
+		// Cloned into another root with --root
+		for _, name := range repo.RootNames() {
+			if other, _ := remote.ClonePathIn(name); other != clonePath {
+				candidates = append(candidates, other)
+			}
+		}
+		for _, candidate := range candidates {
+			if r.manager.PathExists(candidate) {
+				checkout := r.manager.GetFullPath(candidate)
+				remote = r.git.ResolveLinkRefIn(context.Background(), checkout, remote)
+				return linkedPath(checkout, remote.Subpath), nil
+			}
+		}
+		if !remote.Local {
+			return "", fmt.Errorf("%s is not cloned (expected at %s)", target, clonePath)
+		}
+	}
+
+	// Repository names as shown by list, e.g. github.com/user/repo
+	if r.manager.PathExists(target) {
+		return r.manager.GetFullPath(target), nil
+	}
+	return "", fmt.Errorf("%s is not cloned", target)
+}
+
+// existingCheckout reports whether clonePath already holds a clone of
+// remote, see repo.Git.ExistingCheckout
+func (r *Runner) existingCheckout(ctx context.Context, remote repo.RemoteURL, clonePath string) (bool, error) {
+	return r.git.ExistingCheckout(ctx, remote, clonePath, r.manager.GetFullPath(clonePath))
+}
+
+// checkoutRef moves an existing checkout to the requested ref, like sync
+// does for a pinned ref. A checkout with uncommitted changes is an error,
+// as it is left on a ref other than the one asked for.
+func (r *Runner) checkoutRef(ctx context.Context, clonePath, ref string) error {
+	fmt.Fprintf(r.out, "Already cloned at %s, checking out %s...\n", clonePath, ref)
+
+	display := newProgressDisplay([]string{clonePath})
+	display.Set(0, "starting")
+	result := r.git.Checkout(ctx, r.manager.GetFullPath(clonePath), ref, r.strategy, display.Progress(0))
+	display.Finish(0, statusText(result))
+	display.Close()
+	if err := result.CheckoutError(clonePath, ref); err != nil {
+		return err
+	}
+	if result.Skipped() {
+		// On the ref, just not up to date with its upstream
+		fmt.Fprintf(r.out, "Not updated: %s\n", skipText(result.SkipReason))
+	}
+	return nil
+}
+
+// fastForward brings an existing checkout up to date with its upstream
+// without creating merge commits
+func (r *Runner) fastForward(ctx context.Context, clonePath string) error {
+	fmt.Fprintf(r.out, "Already cloned at %s, fast-forwarding...\n", clonePath)
+
+	display := newProgressDisplay([]string{clonePath})
+	display.Set(0, "starting")
+	result := r.git.FastForward(ctx, r.manager.GetFullPath(clonePath), display.Progress(0))
+	display.Finish(0, statusText(result))
+	display.Close()
+	if result.Skipped() {
+		// The checkout is still usable, just not up to date
+		fmt.Fprintf(r.out, "Not fast-forwarded: %s\n", skipText(result.SkipReason))
+		return nil
+	}
+	if !result.Success {
+		return fmt.Errorf("fast-forward failed: %w", result.Error)
+	}
+	if result.SubmoduleError != nil {
+		return fmt.Errorf("fast-forwarded %s, but %w", clonePath, result.SubmoduleError)
+	}
+	return nil
+}
+
+// linkedPath returns the directory a browser link pointed at inside a clone:
+// the directory itself, or the one containing a linked file. It falls back to
+// the clone root when the path does not exist at the checked out ref.
+func linkedPath(destination, subpath string) string {
+	if subpath == "" {
+		return destination
+	}
+
+	target := filepath.Join(destination, filepath.FromSlash(subpath))
+	info, err := os.Stat(target)
+	switch {
+	case err != nil:
+		return destination
+	case info.IsDir():
+		return target
+	default:
+		return filepath.Dir(target)
+	}
+}
+
+// Update updates the repositories the selector picks
+func (r *Runner) Update(ctx context.Context, sel repo.Selector) (string, error) {
+	if sel.IsEmpty() {
 		return "", fmt.Errorf("no repositories specified")
 	}
+	repoNames, err := r.manager.Select(sel)
+	if err != nil {
+		return "", err
+	}
+	if len(repoNames) == 0 {
+		fmt.Fprintln(r.out, "No repositories found.")
+		return "", nil
+	}
+	repoNames = r.withoutCoveredSubmodules(repoNames)
 
 	if len(repoNames) == 1 {
-		return r.updateSingle(repoNames[0])
+		return r.updateSingle(ctx, repoNames[0])
 	}
 
-	err := r.updateMultiple(repoNames)
-	return "", err
+	return "", r.updateMultiple(ctx, repoNames)
+}
+
+// withoutCoveredSubmodules drops the submodules whose superproject is among
+// names, as updating the superproject already updates them
+func (r *Runner) withoutCoveredSubmodules(names []string) []string {
+	var kept []string
+	for _, name := range names {
+		if kind, _ := repo.DetectKind(r.manager.GetFullPath(name)); kind == repo.KindSubmodule {
+			covered := slices.ContainsFunc(names, func(other string) bool {
+				return strings.HasPrefix(name, other+string(filepath.Separator))
+			})
+			if covered {
+				continue
+			}
+		}
+		kept = append(kept, name)
+	}
+	return kept
 }
 
 // updateSingle updates a single repository
-func (r *Runner) updateSingle(repoName string) (string, error) {
+func (r *Runner) updateSingle(ctx context.Context, repoName string) (string, error) {
 	repoPath := r.manager.GetFullPath(repoName)
 
 	if !repo.IsGitRepository(repoPath) {
 		return "", fmt.Errorf("%s is not a git repository", repoName)
 	}
 
-	fmt.Printf("Updating %s...\n", repoName)
+	fmt.Fprintf(r.out, "Updating %s...\n", repoName)
 
-	result := r.git.Pull(repoPath)
+	display := newProgressDisplay([]string{repoName})
+	display.Set(0, "starting")
+	result := r.git.Pull(ctx, repoPath, r.strategy, display.Progress(0))
+	display.Finish(0, statusText(result))
+	display.Close()
+	if result.Skipped() {
+		fmt.Fprintf(r.out, "Skipped %s: %s\n", repoName, skipText(result.SkipReason))
+		return repoPath, nil
+	}
 	if !result.Success {
 		return "", fmt.Errorf("update failed: %w", result.Error)
 	}
+	if result.SubmoduleError != nil {
+		return "", fmt.Errorf("updated %s, but %w", repoName, result.SubmoduleError)
+	}
+	r.git.RecordTracking(ctx, r.tracking, repoName, repoPath)
+	r.saveTracking()
+	r.manager.RecordFetch(repoName)
+	r.saveIndex()
 
-	fmt.Println("Update completed successfully.")
+	fmt.Fprintln(r.out, "Update completed successfully.")
 	if result.Output != "" {
-		fmt.Println(strings.TrimSpace(result.Output))
+		fmt.Fprintln(r.out, strings.TrimSpace(result.Output))
 	}
 
 	return repoPath, nil
 }
 
-// updateMultiple updates multiple repositories in parallel
-func (r *Runner) updateMultiple(repoNames []string) error {
-	var wg sync.WaitGroup
-	results := make(chan updateResult, len(repoNames))
-
-	for _, repoName := range repoNames {
-		wg.Add(1)
-		go func(name string) {
-			defer wg.Done()
-
-			repoPath := r.manager.GetFullPath(name)
-			if !repo.IsGitRepository(repoPath) {
-				results <- updateResult{
-					repoName: name,
-					success:  false,
-					err:      fmt.Errorf("not a git repository"),
+// updateMultiple updates multiple repositories in parallel, bounded by the
+// scheduler, and reports results in the order the repositories were given
+func (r *Runner) updateMultiple(ctx context.Context, repoNames []string) error {
+	results := make([]updateResult, len(repoNames))
+	display := newProgressDisplay(repoNames)
+
+	var jobList []jobs.Job
+	for i, repoName := range repoNames {
+		// Anything the scheduler never starts was cancelled while queued
+		results[i] = updateResult{repoName: repoName, cancelled: true}
+
+		jobList = append(jobList, jobs.Job{
+			Host: repo.RemoteHost(r.manager.GetFullPath(repoName)),
+			Run: func(ctx context.Context) {
+				repoPath := r.manager.GetFullPath(repoName)
+				if !repo.IsGitRepository(repoPath) {
+					display.Finish(i, "✗ not a git repository")
+					results[i] = updateResult{
+						repoName: repoName,
+						success:  false,
+						err:      fmt.Errorf("not a git repository"),
+					}
+					return
 				}
-				return
-			}
 
-			result := r.git.Pull(repoPath)
-			results <- updateResult{
-				repoName: name,
-				success:  result.Success,
-				output:   result.Output,
-				err:      result.Error,
-			}
-		}(repoName)
+				display.Set(i, "starting")
+				result := r.git.Pull(ctx, repoPath, r.strategy, display.Progress(i))
+				if result.Success {
+					r.git.RecordTracking(ctx, r.tracking, repoName, repoPath)
+					r.manager.RecordFetch(repoName)
+				}
+				display.Finish(i, statusText(result))
+				results[i] = updateResult{
+					repoName:     repoName,
+					success:      result.Success,
+					cancelled:    result.Cancelled(),
+					skipped:      result.SkipReason,
+					output:       result.Output,
+					err:          result.Error,
+					submoduleErr: result.SubmoduleError,
+				}
+			},
+		})
 	}
 
-	// Wait for all updates to complete
-	wg.Wait()
-	close(results)
+	r.scheduler.Run(ctx, jobList)
+	display.Close()
+	r.saveTracking()
+	r.saveIndex()
 
 	// Print results
 	successCount := 0
 	failCount := 0
-
-	fmt.Println("\nUpdate Results:")
-	fmt.Println(strings.Repeat("-", 50))
-
-	for result := range results {
-		if result.success {
+	cancelCount := 0
+	skipCount := 0
+
+	fmt.Fprintln(r.out, "\nUpdate Results:")
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+
+	for _, result := range results {
+		switch {
+		case result.skipped != "":
+			skipCount++
+			fmt.Fprintf(r.out, "↷ %s: Skipped: %s\n", result.repoName, skipText(result.skipped))
+		case result.success && result.submoduleErr != nil:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: Updated, but %v\n", result.repoName, result.submoduleErr)
+		case result.success:
 			successCount++
-			fmt.Printf("✓ %s: Updated successfully\n", result.repoName)
-		} else {
+			fmt.Fprintf(r.out, "✓ %s: Updated successfully\n", result.repoName)
+		case result.cancelled:
+			cancelCount++
+			fmt.Fprintf(r.out, "⊘ %s: Cancelled\n", result.repoName)
+		default:
 			failCount++
-			fmt.Printf("✗ %s: Failed - %v\n", result.repoName, result.err)
+			fmt.Fprintf(r.out, "✗ %s: Failed - %v\n", result.repoName, result.err)
 		}
 	}
 
-	fmt.Println(strings.Repeat("-", 50))
-	fmt.Printf("Summary: %d succeeded, %d failed\n", successCount, failCount)
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+	fmt.Fprintf(r.out, "Summary: %s\n", summaryText(successCount, failCount, cancelCount, skipCount))
 
+	if cancelCount > 0 {
+		return fmt.Errorf("update interrupted: %w", context.Canceled)
+	}
 	if failCount > 0 {
 		return fmt.Errorf("%d updates failed", failCount)
 	}
@@ -174,159 +503,581 @@ func (r *Runner) updateMultiple(repoNames []string) error {
 	return nil
 }
 
-// Remove removes one or more repositories
-func (r *Runner) Remove(repoNames []string, force bool) error {
+// Fetch downloads upstream changes of the selected repositories, or of all
+// repositories when the selector is empty, without touching their working
+// trees, and records how far each branch is ahead of and behind its upstream
+func (r *Runner) Fetch(ctx context.Context, sel repo.Selector) error {
+	repoNames, err := r.manager.Select(sel)
+	if err != nil {
+		return err
+	}
 	if len(repoNames) == 0 {
-		return fmt.Errorf("no repositories specified")
+		fmt.Fprintln(r.out, "No repositories found.")
+		return nil
 	}
 
-	// Verify all repos exist first
-	for _, repoName := range repoNames {
-		if !r.manager.PathExists(repoName) {
-			return fmt.Errorf("repository %s not found", repoName)
+	results := make([]fetchResult, len(repoNames))
+	display := newProgressDisplay(repoNames)
+
+	var jobList []jobs.Job
+	for i, repoName := range repoNames {
+		// Anything the scheduler never starts was cancelled while queued
+		results[i] = fetchResult{repoName: repoName, cancelled: true}
+
+		jobList = append(jobList, jobs.Job{
+			Host: repo.RemoteHost(r.manager.GetFullPath(repoName)),
+			Run: func(ctx context.Context) {
+				repoPath := r.manager.GetFullPath(repoName)
+				if !repo.IsGitRepository(repoPath) {
+					display.Finish(i, "✗ not a git repository")
+					results[i] = fetchResult{repoName: repoName, err: fmt.Errorf("not a git repository")}
+					return
+				}
+
+				display.Set(i, "starting")
+				result := r.git.Fetch(ctx, repoPath, display.Progress(i))
+				results[i] = fetchResult{
+					repoName:  repoName,
+					success:   result.Success,
+					cancelled: result.Cancelled(),
+					err:       result.Error,
+				}
+				if result.Success {
+					results[i].tracking, results[i].tracked = r.git.RecordTracking(ctx, r.tracking, repoName, repoPath)
+					r.manager.RecordFetch(repoName)
+				}
+				display.Finish(i, statusText(result))
+			},
+		})
+	}
+
+	r.scheduler.Run(ctx, jobList)
+	display.Close()
+	r.saveTracking()
+	r.saveIndex()
+
+	successCount := 0
+	failCount := 0
+	cancelCount := 0
+
+	fmt.Fprintln(r.out, "\nFetch Results:")
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+
+	for _, result := range results {
+		switch {
+		case result.success:
+			successCount++
+			switch {
+			case !result.tracked:
+				fmt.Fprintf(r.out, "✓ %s: no upstream\n", result.repoName)
+			case result.tracking.Badge() == "":
+				fmt.Fprintf(r.out, "✓ %s: up to date\n", result.repoName)
+			default:
+				fmt.Fprintf(r.out, "✓ %s: %s\n", result.repoName, result.tracking.Badge())
+			}
+		case result.cancelled:
+			cancelCount++
+			fmt.Fprintf(r.out, "⊘ %s: Cancelled\n", result.repoName)
+		default:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: Failed - %v\n", result.repoName, result.err)
 		}
 	}
 
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+	fmt.Fprintf(r.out, "Summary: %s\n", summaryText(successCount, failCount, cancelCount, 0))
+
+	if cancelCount > 0 {
+		return fmt.Errorf("fetch interrupted: %w", context.Canceled)
+	}
+	if failCount > 0 {
+		return fmt.Errorf("%d fetches failed", failCount)
+	}
+	return nil
+}
+
+// saveTracking persists ahead/behind counts; failing to do so only loses
+// badges, so it is not an error for the command
+func (r *Runner) saveTracking() {
+	if err := r.tracking.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// saveIndex persists the repository index; failing to do so only makes the
+// next command scan the disk, so it is not an error for the command
+func (r *Runner) saveIndex() {
+	if err := r.manager.SaveIndex(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// Remove removes the repositories the selector picks
+func (r *Runner) Remove(sel repo.Selector, force bool) error {
+	if sel.IsEmpty() {
+		return fmt.Errorf("no repositories specified")
+	}
+	// Filters and excludes on their own would pick most of the workspace
+	if !sel.IsExplicit() {
+		return fmt.Errorf("remove needs a repository name or pattern, or --all to remove everything the filters allow")
+	}
+	repoNames, err := r.manager.Select(sel)
+	if err != nil {
+		return err
+	}
+	if len(repoNames) == 0 {
+		fmt.Fprintln(r.out, "No repositories found.")
+		return nil
+	}
+
 	// Confirm removal if not forced
 	if !force {
-		fmt.Printf("Are you sure you want to remove the following repositories?\n")
+		fmt.Fprintf(r.out, "Are you sure you want to remove the following repositories?\n")
 		for _, name := range repoNames {
-			fmt.Printf("  - %s\n", name)
+			fmt.Fprintf(r.out, "  - %s\n", name)
 		}
-		fmt.Print("\nThis action cannot be undone. Continue? [y/N] ")
+		fmt.Fprint(r.out, "\nThis action cannot be undone. Continue? [y/N] ")
 
 		reader := bufio.NewReader(os.Stdin)
 		input, _ := reader.ReadString('\n')
 		if strings.TrimSpace(strings.ToLower(input)) != "y" {
-			fmt.Println("Remove cancelled.")
+			fmt.Fprintln(r.out, "Remove cancelled.")
 			return nil
 		}
 	}
 
 	// Remove repositories
+	var removed []string
+	defer func() {
+		r.forgetTags(removed)
+		r.saveIndex()
+	}()
 	for _, repoName := range repoNames {
 		repoPath := r.manager.GetFullPath(repoName)
-		fmt.Printf("Removing %s...\n", repoName)
+		fmt.Fprintf(r.out, "Removing %s...\n", repoName)
 
 		if err := os.RemoveAll(repoPath); err != nil {
 			return fmt.Errorf("failed to remove %s: %w", repoName, err)
 		}
+		r.manager.RecordRemoval(repoName)
+		removed = append(removed, repoName)
+	}
+
+	fmt.Fprintf(r.out, "Successfully removed %d repositories.\n", len(repoNames))
+	return nil
+}
+
+// Unshallow converts shallow, partial or single-branch clones into full
+// clones. Repositories may be given by name or by URL.
+func (r *Runner) Unshallow(ctx context.Context, targets []string) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("no repositories specified")
+	}
+
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		names[i] = target
+		if !r.manager.PathExists(target) {
+			if remote, err := repo.ResolveURL(target); err == nil {
+				names[i] = remote.ClonePath()
+			}
+		}
+		if !repo.IsGitRepository(r.manager.GetFullPath(names[i])) {
+			return fmt.Errorf("repository %s not found", target)
+		}
+	}
+
+	display := newProgressDisplay(names)
+	results := make([]repo.GitOperation, len(names))
+	for i, name := range names {
+		display.Set(i, "starting")
+		results[i] = r.git.Unshallow(ctx, r.manager.GetFullPath(name), display.Progress(i))
+		display.Finish(i, statusText(results[i]))
+		if results[i].Cancelled() {
+			break
+		}
 	}
+	display.Close()
 
-	fmt.Printf("Successfully removed %d repositories.\n", len(repoNames))
+	failCount := 0
+	for i, result := range results {
+		switch {
+		case result.Success:
+			fmt.Fprintf(r.out, "✓ %s: %s\n", names[i], result.Output)
+		case result.Error == nil:
+			// Not started because an earlier one was cancelled
+		default:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: %v\n", names[i], result.Error)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("unshallow interrupted: %w", context.Canceled)
+	}
+	if failCount > 0 {
+		return fmt.Errorf("%d repositories could not be unshallowed", failCount)
+	}
+	return nil
+}
+
+// Relocate moves existing checkouts to the paths the current layout gives
+// their origin remotes. With dryRun it only reports the planned moves.
+// Submodules, worktrees and bare repositories stay where they are: they
+// belong to their superproject or main checkout, whose origin they share.
+func (r *Runner) Relocate(ctx context.Context, dryRun bool) error {
+	repos, err := r.manager.List()
+	if err != nil {
+		return fmt.Errorf("error scanning repositories: %w", err)
+	}
+
+	moved, unchanged, failed := 0, 0, 0
+	claimed := make(map[string]string) // Target path -> current path
+	for _, rp := range repos {
+		if !rp.IsGitDir || rp.Kind != repo.KindNormal {
+			continue
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("relocate interrupted: %w", ctx.Err())
+		}
+
+		current := filepath.ToSlash(rp.Name)
+		remoteURL, err := r.git.GetRemoteURL(ctx, rp.Path)
+		if err != nil {
+			failed++
+			fmt.Fprintf(r.out, "✗ %s: no origin remote\n", current)
+			continue
+		}
+		remote, err := repo.ResolveURL(remoteURL)
+		if err != nil {
+			failed++
+			fmt.Fprintf(r.out, "✗ %s: unsupported remote %s\n", current, remoteURL)
+			continue
+		}
+
+		// Checkouts stay in their root, whatever its match rules say
+		target := remote.ClonePath()
+		if rootName := repo.RootOf(current); rootName != "" {
+			target, _ = remote.ClonePathIn(rootName)
+		}
+		if target == current {
+			unchanged++
+			continue
+		}
+		if other, ok := claimed[target]; ok {
+			failed++
+			fmt.Fprintf(r.out, "✗ %s: %s is also the target of %s\n", current, target, other)
+			continue
+		}
+		claimed[target] = current
+
+		if dryRun {
+			moved++
+			fmt.Fprintf(r.out, "%s → %s\n", current, target)
+			continue
+		}
+		if err := r.manager.Move(rp.Name, target); err != nil {
+			failed++
+			fmt.Fprintf(r.out, "✗ %s: %v\n", current, err)
+			continue
+		}
+		moved++
+		fmt.Fprintf(r.out, "✓ %s → %s\n", current, target)
+		repo.Tags().Rename(current, target)
+	}
+	if !dryRun {
+		if err := repo.Tags().Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		r.saveIndex()
+	}
+
+	verb := "Moved"
+	if dryRun {
+		verb = "Would move"
+	}
+	fmt.Fprintf(r.out, "%s %d repositories, %d already in place, %d failed\n", verb, moved, unchanged, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d repositories could not be relocated", failed)
+	}
 	return nil
 }
 
 type updateResult struct {
-	repoName string
-	success  bool
-	output   string
-	err      error
+	repoName     string
+	success      bool
+	cancelled    bool
+	skipped      string // Skip reason, see repo.GitOperation.SkipReason
+	output       string
+	err          error
+	submoduleErr error
+}
+
+type fetchResult struct {
+	repoName  string
+	success   bool
+	cancelled bool
+	tracking  repo.Tracking
+	tracked   bool // The branch has an upstream
+	err       error
 }
 
 type cloneResult struct {
-	url      string
-	repoPath string
-	success  bool
-	output   string
-	err      error
+	url          string
+	repoPath     string
+	existing     bool // Already cloned before this run
+	success      bool
+	cancelled    bool
+	skipped      string // Why an existing checkout was not fast-forwarded
+	output       string
+	err          error
+	submoduleErr error
 }
 
-// CloneMultiple clones multiple repositories in parallel
-func (r *Runner) CloneMultiple(urls []string) error {
-	if len(urls) == 0 {
-		return fmt.Errorf("no URLs specified")
+// statusText describes the final state of a git operation for the live display
+func statusText(result repo.GitOperation) string {
+	switch {
+	case result.Skipped():
+		return "↷ skipped: " + result.SkipReason
+	case result.Success && result.SubmoduleError != nil:
+		return "✗ submodules failed"
+	case result.Success:
+		return "✓ done"
+	case result.Cancelled():
+		return "⊘ cancelled"
+	default:
+		return "✗ failed"
 	}
+}
+
+// skipText explains a skip reason, e.g. "dirty (uncommitted changes)"
+func skipText(reason string) string {
+	switch reason {
+	case repo.SkipDirty:
+		return reason + " (uncommitted changes)"
+	case repo.SkipDetached:
+		return reason + " (HEAD is not on a branch)"
+	case repo.SkipNoUpstream:
+		return reason + " (branch does not track a remote branch)"
+	case repo.SkipSubmodule:
+		return reason + " (update its superproject instead)"
+	default:
+		return reason
+	}
+}
+
+// existingStatusText describes the final state of an already cloned repository
+func existingStatusText(result repo.GitOperation) string {
+	if result.Success && result.SubmoduleError == nil {
+		return "✓ already cloned"
+	}
+	return statusText(result)
+}
+
+// CloneTarget is a URL to clone together with its clone options
+type CloneTarget struct {
+	URL     string
+	Options repo.CloneOptions
+}
 
-	// Remove duplicates
-	uniqueURLs := make(map[string]bool)
-	var urlList []string
-	for _, url := range urls {
-		if !uniqueURLs[url] {
-			uniqueURLs[url] = true
-			urlList = append(urlList, url)
+// uniqueTargets drops repeated URLs, keeping the first occurrence. URLs that
+// resolve to the same repository (e.g. its SSH and HTTPS forms) count as one.
+func uniqueTargets(targets []CloneTarget) []CloneTarget {
+	seen := make(map[string]bool)
+	var unique []CloneTarget
+	for _, target := range targets {
+		if key := urlKey(target.URL); !seen[key] {
+			seen[key] = true
+			unique = append(unique, target)
 		}
 	}
+	return unique
+}
 
-	if len(urlList) == 1 {
-		_, err := r.Clone(urlList[0])
-		return err
+// summaryText formats the closing line of a bulk operation
+func summaryText(succeeded, failed, cancelled, skipped int) string {
+	summary := fmt.Sprintf("%d succeeded, %d failed", succeeded, failed)
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
 	}
+	if cancelled > 0 {
+		summary += fmt.Sprintf(", %d cancelled", cancelled)
+	}
+	return summary
+}
 
-	var wg sync.WaitGroup
-	results := make(chan cloneResult, len(urlList))
+// CloneMultiple clones multiple repositories in parallel, bounded by the
+// scheduler, and reports results in the order the targets were given.
+// Cancelling ctx stops outstanding clones and removes their partial checkouts.
+func (r *Runner) CloneMultiple(ctx context.Context, targets []CloneTarget) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("no URLs specified")
+	}
 
-	fmt.Printf("Cloning %d repositories...\n\n", len(urlList))
+	// Remove duplicates, including different spellings of the same remote
+	targets = uniqueTargets(targets)
 
-	for _, url := range urlList {
-		wg.Add(1)
-		go func(url string) {
-			defer wg.Done()
+	if len(targets) == 1 {
+		_, err := r.Clone(ctx, targets[0].URL, targets[0].Options)
+		return err
+	}
 
-			// Expand short notation
-			expandedURL := repo.ExpandShortNotation(url)
+	results := make([]cloneResult, len(targets))
+	labels := make([]string, len(targets))
+	for i, target := range targets {
+		labels[i] = target.URL
+	}
 
-			// Validate URL
-			if err := repo.ValidateURL(expandedURL); err != nil {
-				results <- cloneResult{
-					url:     url,
-					success: false,
-					err:     fmt.Errorf("invalid URL: %w", err),
-				}
-				return
+	fmt.Fprintf(r.out, "Cloning %d repositories (%d at a time)...\n\n", len(targets), r.scheduler.Limit())
+	display := newProgressDisplay(labels)
+
+	var jobList []jobs.Job
+	claimed := make(map[string]string) // Clone path -> URL, to catch layout collisions
+	for i, target := range targets {
+		url := target.URL
+		// Anything the scheduler never starts was cancelled while queued
+		results[i] = cloneResult{url: url, cancelled: true}
+
+		// Expand short notation and validate URL
+		remote, err := repo.ResolveURL(url)
+		if err != nil {
+			display.Finish(i, "✗ invalid URL")
+			results[i] = cloneResult{
+				url:     url,
+				success: false,
+				err:     fmt.Errorf("invalid URL: %w", err),
 			}
+			continue
+		}
+		// Get destination path; SetRoot has already checked the root
+		clonePath, _ := r.clonePath(remote)
+		destination := r.manager.GetFullPath(clonePath)
+		if other, ok := claimed[clonePath]; ok {
+			display.Finish(i, "✗ path conflict")
+			results[i] = cloneResult{
+				url:      url,
+				repoPath: clonePath,
+				success:  false,
+				err:      fmt.Errorf("clone path %s is also used by %s", clonePath, other),
+			}
+			continue
+		}
+		claimed[clonePath] = url
+
+		jobList = append(jobList, jobs.Job{
+			Host: remote.Host,
+			Run: func(ctx context.Context) {
+				// Reuse an existing clone of the same remote
+				if exists, err := r.existingCheckout(ctx, remote, clonePath); err != nil {
+					display.Finish(i, "✗ already exists")
+					results[i] = cloneResult{url: url, repoPath: clonePath, err: err}
+					return
+				} else if exists {
+					// A browser link's ref ends where the checkout's branch names say
+					remote = r.git.ResolveLinkRefIn(ctx, destination, remote)
+					result := repo.GitOperation{Success: true}
+					if ref := remote.RequestedRef(target.Options); ref != "" {
+						display.Set(i, "checking out "+ref)
+						result = r.git.Checkout(ctx, destination, ref, r.strategy, display.Progress(i))
+						if err := result.CheckoutError(clonePath, ref); err != nil && !result.Cancelled() {
+							display.Finish(i, "✗ not switched")
+							results[i] = cloneResult{url: url, repoPath: destination, existing: true, err: err}
+							return
+						}
+					} else if r.pullExisting {
+						display.Set(i, "fast-forwarding")
+						result = r.git.FastForward(ctx, destination, display.Progress(i))
+					}
+					display.Finish(i, existingStatusText(result))
+					results[i] = cloneResult{
+						url:          url,
+						repoPath:     destination,
+						existing:     true,
+						success:      result.Success || result.Skipped(),
+						cancelled:    result.Cancelled(),
+						skipped:      result.SkipReason,
+						output:       result.Output,
+						err:          result.Error,
+						submoduleErr: result.SubmoduleError,
+					}
+					return
+				}
 
-			// Get destination path
-			clonePath := repo.GetClonePath(expandedURL)
-			destination := r.manager.GetFullPath(clonePath)
-
-			// Check if already exists
-			if r.manager.PathExists(clonePath) {
-				results <- cloneResult{
-					url:      url,
-					repoPath: clonePath,
-					success:  false,
-					err:      fmt.Errorf("repository already exists"),
+				// Looked up here, so the lookups run under the scheduler's limits
+				remote, err := r.git.ResolveLinkRef(ctx, remote)
+				if err != nil {
+					display.Finish(i, "✗ unknown ref")
+					results[i] = cloneResult{
+						url:       url,
+						repoPath:  clonePath,
+						cancelled: ctx.Err() != nil,
+						err:       fmt.Errorf("failed to look up the ref: %w", err),
+					}
+					return
 				}
-				return
-			}
 
-			// Perform clone
-			result := r.git.Clone(expandedURL, destination)
-			results <- cloneResult{
-				url:      url,
-				repoPath: destination,
-				success:  result.Success,
-				output:   result.Output,
-				err:      result.Error,
-			}
-		}(url)
+				// Perform clone
+				display.Set(i, "starting")
+				result := r.git.Clone(ctx, remote.CloneURL(), destination, remote.ResolveOptions(target.Options), display.Progress(i))
+				if result.Success {
+					r.manager.RecordFetch(clonePath)
+				}
+				display.Finish(i, statusText(result))
+				results[i] = cloneResult{
+					url:          url,
+					repoPath:     destination,
+					success:      result.Success,
+					cancelled:    result.Cancelled(),
+					output:       result.Output,
+					err:          result.Error,
+					submoduleErr: result.SubmoduleError,
+				}
+			},
+		})
 	}
 
-	// Wait for all clones to complete
-	wg.Wait()
-	close(results)
+	r.scheduler.Run(ctx, jobList)
+	display.Close()
+	r.saveIndex()
 
 	// Print results
 	successCount := 0
 	failCount := 0
+	cancelCount := 0
 
-	fmt.Println("Clone Results:")
-	fmt.Println(strings.Repeat("-", 50))
+	fmt.Fprintln(r.out, "Clone Results:")
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
 
-	for result := range results {
-		if result.success {
+	for _, result := range results {
+		switch {
+		case result.success && result.submoduleErr != nil:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: Cloned to %s, but %v\n", result.url, result.repoPath, result.submoduleErr)
+		case result.success:
 			successCount++
-			fmt.Printf("✓ %s: Cloned to %s\n", result.url, result.repoPath)
-		} else {
+			if result.skipped != "" {
+				fmt.Fprintf(r.out, "✓ %s: Already cloned at %s, not fast-forwarded: %s\n", result.url, result.repoPath, skipText(result.skipped))
+			} else if result.existing {
+				fmt.Fprintf(r.out, "✓ %s: Already cloned at %s\n", result.url, result.repoPath)
+			} else {
+				fmt.Fprintf(r.out, "✓ %s: Cloned to %s\n", result.url, result.repoPath)
+			}
+		case result.cancelled:
+			cancelCount++
+			fmt.Fprintf(r.out, "⊘ %s: Cancelled\n", result.url)
+		default:
 			failCount++
-			fmt.Printf("✗ %s: Failed - %v\n", result.url, result.err)
+			fmt.Fprintf(r.out, "✗ %s: Failed - %v\n", result.url, result.err)
 		}
 	}
 
-	fmt.Println(strings.Repeat("-", 50))
-	fmt.Printf("Summary: %d succeeded, %d failed\n", successCount, failCount)
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+	fmt.Fprintf(r.out, "Summary: %s\n", summaryText(successCount, failCount, cancelCount, 0))
 
+	if cancelCount > 0 {
+		return fmt.Errorf("clone interrupted: %w", context.Canceled)
+	}
 	if failCount > 0 {
 		return fmt.Errorf("%d clones failed", failCount)
 	}
@@ -334,15 +1085,16 @@ func (r *Runner) CloneMultiple(urls []string) error {
 	return nil
 }
 
-// ParseCloneFile reads URLs from a file, skipping comments and empty lines
-func (r *Runner) ParseCloneFile(filepath string) ([]string, error) {
+// ParseCloneFile reads URLs and their per-line clone options from a file,
+// skipping comments and empty lines. Line options take precedence over defaults.
+func (r *Runner) ParseCloneFile(filepath string, defaults repo.CloneOptions) ([]CloneTarget, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	var urls []string
+	var targets []CloneTarget
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
 
@@ -355,18 +1107,25 @@ func (r *Runner) ParseCloneFile(filepath string) ([]string, error) {
 			continue
 		}
 
+		// Options after the URL apply to that line only
+		url, opts, err := repo.ParseCloneSpec(line)
+		if err != nil {
+			fmt.Fprintf(r.out, "Warning: skipping line %d: %v\n", lineNum, err)
+			continue
+		}
+
 		// Validate URL
-		if err := repo.ValidateURL(line); err != nil {
-			fmt.Printf("Warning: skipping invalid URL on line %d: %s\n", lineNum, line)
+		if err := repo.ValidateURL(url); err != nil {
+			fmt.Fprintf(r.out, "Warning: skipping invalid URL on line %d: %s\n", lineNum, line)
 			continue
 		}
 
-		urls = append(urls, line)
+		targets = append(targets, CloneTarget{URL: url, Options: defaults.Merge(opts)})
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
-	return urls, nil
+	return targets, nil
 }
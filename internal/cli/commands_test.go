@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"get-repo/config"
+	"get-repo/internal/repo"
+)
+
+// testRunner returns a runner over a fresh codebases directory, with the
+// cache and git's global configuration kept out of the user's home
+func testRunner(t *testing.T) (*Runner, string, *bytes.Buffer) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv(config.EnvCacheDir, t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	// Submodules of local test repositories are cloned over the file protocol
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	base := t.TempDir()
+	r := NewRunner(config.Config{CodebasesPath: base, Jobs: 2})
+	var out bytes.Buffer
+	r.SetOutput(&out)
+	return r, base, &out
+}
+
+// gitIn runs git in dir and returns its output
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "init.defaultBranch=main"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+// initRepo creates a repository with one commit at dir whose origin is url
+func initRepo(t *testing.T, dir, url string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte(url+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "README")
+	gitIn(t, dir, "commit", "-q", "-m", "initial")
+	gitIn(t, dir, "remote", "add", "origin", url)
+}
+
+// superproject creates a checkout at base/name with origin url and a
+// submodule at vendor/lib whose origin is libURL
+func superproject(t *testing.T, base, name, url, libURL string) string {
+	t.Helper()
+	lib := filepath.Join(t.TempDir(), "lib")
+	initRepo(t, lib, libURL)
+
+	app := filepath.Join(base, name)
+	initRepo(t, app, url)
+	gitIn(t, app, "submodule", "add", "-q", lib, "vendor/lib")
+	gitIn(t, app, "commit", "-q", "-m", "add lib")
+	gitIn(t, filepath.Join(app, "vendor", "lib"), "remote", "set-url", "origin", libURL)
+	return app
+}
+
+func TestRelocateKeepsSubmodules(t *testing.T) {
+	r, base, out := testRunner(t)
+	superproject(t, base, "app", "https://github.com/me/app", "https://github.com/me/lib")
+
+	if err := r.Relocate(context.Background(), false); err != nil {
+		t.Fatalf("Relocate: %v\n%s", err, out)
+	}
+
+	moved := filepath.Join(base, "github.com", "me", "app")
+	if _, err := os.Stat(filepath.Join(moved, "vendor", "lib", "README")); err != nil {
+		t.Errorf("submodule did not move with its superproject: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(base, "github.com", "me", "lib")); !os.IsNotExist(err) {
+		t.Errorf("submodule was relocated on its own\n%s", out)
+	}
+	if status := gitIn(t, moved, "status", "--porcelain"); status != "" {
+		t.Errorf("superproject has changes after relocating:\n%s", status)
+	}
+}
+
+func TestCloneExistingCheckoutRef(t *testing.T) {
+	r, base, out := testRunner(t)
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "src")
+	initRepo(t, src, "https://example.com/unused")
+	gitIn(t, src, "tag", "v1")
+	gitIn(t, src, "commit", "-q", "--allow-empty", "-m", "second")
+	gitIn(t, src, "branch", "feature")
+	origin := filepath.Join(t.TempDir(), "app.git")
+	gitIn(t, base, "clone", "-q", "--bare", src, origin)
+
+	checkout, err := r.Clone(ctx, origin, repo.CloneOptions{})
+	if err != nil {
+		t.Fatalf("Clone: %v\n%s", err, out)
+	}
+	head := func() string {
+		return strings.TrimSpace(gitIn(t, checkout, "rev-parse", "HEAD"))
+	}
+	v1 := strings.TrimSpace(gitIn(t, checkout, "rev-parse", "v1^{commit}"))
+
+	if _, err := r.Clone(ctx, origin+"@v1", repo.CloneOptions{}); err != nil {
+		t.Fatalf("Clone @v1 of an existing checkout: %v\n%s", err, out)
+	}
+	if got := head(); got != v1 {
+		t.Errorf("HEAD = %s after cloning @v1 again, want %s", got, v1)
+	}
+
+	if err := os.WriteFile(filepath.Join(checkout, "README"), []byte("local edit\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Clone(ctx, origin, repo.CloneOptions{Ref: "feature"})
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("Clone --ref feature of a dirty checkout: err = %v, want uncommitted changes", err)
+	}
+	if got := head(); got != v1 {
+		t.Errorf("dirty checkout moved to %s", got)
+	}
+}
+
+func TestCloneMultipleExistingCheckout(t *testing.T) {
+	r, base, out := testRunner(t)
+	ctx := context.Background()
+
+	var origins []string
+	for _, name := range []string{"one", "two"} {
+		src := filepath.Join(t.TempDir(), name)
+		initRepo(t, src, "https://example.com/unused")
+		origin := filepath.Join(t.TempDir(), name+".git")
+		gitIn(t, base, "clone", "-q", "--bare", src, origin)
+		origins = append(origins, origin)
+	}
+	if _, err := r.Clone(ctx, origins[0], repo.CloneOptions{}); err != nil {
+		t.Fatalf("Clone: %v\n%s", err, out)
+	}
+	out.Reset()
+
+	targets := []CloneTarget{{URL: origins[0]}, {URL: origins[1]}}
+	if err := r.CloneMultiple(ctx, targets); err != nil {
+		t.Fatalf("CloneMultiple: %v\n%s", err, out)
+	}
+	if !strings.Contains(out.String(), "Already cloned") || !strings.Contains(out.String(), "Cloned to") {
+		t.Errorf("want one reused and one new checkout:\n%s", out)
+	}
+}
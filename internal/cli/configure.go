@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"get-repo/config"
+	"get-repo/internal/repo"
+	"path/filepath"
+)
+
+// ApplyConfig installs the process-wide repository settings from cfg,
+// such as custom shorthand providers, URL rewrites, the clone layout, clone
+// defaults, the submodule and scan policies, the roots, groups and tags. Call
+// it once after loading the config.
+func ApplyConfig(cfg config.Config) error {
+	providers := make([]repo.Provider, 0, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		providers = append(providers, repo.Provider{
+			Name:         p.Name,
+			Aliases:      p.Aliases,
+			Host:         p.Host,
+			Protocol:     p.Protocol,
+			DefaultOwner: p.DefaultOwner,
+			Web:          p.Web,
+		})
+	}
+	if err := repo.SetProviders(providers); err != nil {
+		return fmt.Errorf("invalid providers configuration: %w", err)
+	}
+
+	rules := make([]repo.RewriteRule, 0, len(cfg.URLRewrites))
+	for _, r := range cfg.URLRewrites {
+		rules = append(rules, repo.RewriteRule{
+			Host:      r.Host,
+			Protocol:  r.Protocol,
+			URL:       r.URL,
+			InsteadOf: r.InsteadOf,
+		})
+	}
+	if err := repo.SetRewriteRules(rules); err != nil {
+		return fmt.Errorf("invalid url_rewrites configuration: %w", err)
+	}
+
+	if err := repo.SetLayout(repo.Layout{
+		Template:    cfg.Layout.Template,
+		Lowercase:   cfg.Layout.Lowercase,
+		HostAliases: cfg.Layout.HostAliases,
+	}); err != nil {
+		return fmt.Errorf("invalid layout configuration: %w", err)
+	}
+
+	defaults := make([]repo.CloneDefault, 0, len(cfg.CloneDefaults))
+	for _, d := range cfg.CloneDefaults {
+		defaults = append(defaults, repo.CloneDefault{
+			Match: d.Match,
+			Options: repo.CloneOptions{
+				Depth:        d.Depth,
+				Filter:       d.Filter,
+				SingleBranch: d.SingleBranch,
+				Sparse:       d.Sparse,
+			},
+		})
+	}
+	if err := repo.SetCloneDefaults(defaults); err != nil {
+		return fmt.Errorf("invalid clone_defaults configuration: %w", err)
+	}
+
+	if err := repo.SetSubmodulePolicy(repo.SubmodulePolicy{
+		Disabled: cfg.Submodules.Disabled,
+		Exclude:  cfg.Submodules.Exclude,
+	}); err != nil {
+		return fmt.Errorf("invalid submodules configuration: %w", err)
+	}
+
+	if err := repo.SetScanPolicy(repo.ScanPolicy{
+		MaxDepth: cfg.Scan.MaxDepth,
+		Ignore:   cfg.Scan.Ignore,
+		Nested:   cfg.Scan.Nested,
+	}); err != nil {
+		return fmt.Errorf("invalid scan configuration: %w", err)
+	}
+
+	if err := repo.SetRoots(configuredRoots(cfg)); err != nil {
+		return fmt.Errorf("invalid roots configuration: %w", err)
+	}
+
+	if _, err := repo.ParseUpdateStrategy(cfg.UpdateStrategy); err != nil {
+		return fmt.Errorf("invalid update_strategy configuration: %w", err)
+	}
+
+	if err := repo.SetGroups(cfg.Groups); err != nil {
+		return fmt.Errorf("invalid groups configuration: %w", err)
+	}
+
+	// Without a config directory the store starts empty and cannot be saved
+	tagsPath, _ := config.DataPath(repo.TagsFileName)
+	tags, err := repo.LoadTagStore(tagsPath)
+	if err != nil {
+		return err
+	}
+	repo.SetTagStore(tags)
+
+	return nil
+}
+
+// configuredRoots returns the roots to install. With roots configured, the
+// codebases directory becomes the first of them, named "default", unless
+// one of them already uses it. Without, there are none.
+func configuredRoots(cfg config.Config) []repo.Root {
+	if len(cfg.Roots) == 0 {
+		return nil
+	}
+
+	var roots []repo.Root
+	includesCodebases := false
+	for _, r := range cfg.Roots {
+		if filepath.Clean(r.Path) == filepath.Clean(cfg.CodebasesPath) {
+			includesCodebases = true
+		}
+		roots = append(roots, repo.Root{
+			Name: r.Name,
+			Path: r.Path,
+			Layout: repo.Layout{
+				Template:    r.Layout.Template,
+				Lowercase:   r.Layout.Lowercase,
+				HostAliases: r.Layout.HostAliases,
+			},
+			Match: r.Match,
+		})
+	}
+	if !includesCodebases && cfg.CodebasesPath != "" {
+		roots = append([]repo.Root{{Name: repo.DefaultRootName, Path: cfg.CodebasesPath}}, roots...)
+	}
+	return roots
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"get-repo/internal/jobs"
+	"get-repo/internal/repo"
+	"io"
+	"strings"
+	"sync"
+)
+
+type execResult struct {
+	repoName  string
+	exitCode  int
+	cancelled bool
+	err       error
+}
+
+// Exec runs a command in each selected repository, or in all repositories
+// when the selector is empty, in parallel bounded by the scheduler.
+// Output is printed per repository once its command finishes, or line by line
+// with a name prefix when prefix is set. Results are summarized in the order
+// the repositories were given.
+func (r *Runner) Exec(ctx context.Context, sel repo.Selector, argv []string, prefix bool) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("no command given")
+	}
+	repoNames, err := r.manager.Select(sel)
+	if err != nil {
+		return err
+	}
+	if len(repoNames) == 0 {
+		fmt.Fprintln(r.out, "No repositories found.")
+		return nil
+	}
+
+	width := 0
+	for _, name := range repoNames {
+		width = max(width, len(name))
+	}
+
+	// Serializes writes so output of different repositories never interleaves
+	// within a line (prefixed) or a block (grouped)
+	var outMu sync.Mutex
+	results := make([]execResult, len(repoNames))
+
+	var jobList []jobs.Job
+	for i, repoName := range repoNames {
+		// Anything the scheduler never starts was cancelled while queued
+		results[i] = execResult{repoName: repoName, cancelled: true}
+
+		jobList = append(jobList, jobs.Job{
+			Host: repo.RemoteHost(r.manager.GetFullPath(repoName)),
+			Run: func(ctx context.Context) {
+				repoPath := r.manager.GetFullPath(repoName)
+				if !r.manager.PathExists(repoName) {
+					results[i] = execResult{repoName: repoName, exitCode: -1, err: fmt.Errorf("repository not found")}
+					return
+				}
+
+				var out io.Writer
+				var buffer bytes.Buffer
+				var lines *prefixWriter
+				if prefix {
+					lines = &prefixWriter{mu: &outMu, out: r.out, prefix: fmt.Sprintf("%-*s | ", width, repoName)}
+					out = lines
+				} else {
+					out = &buffer
+				}
+
+				err := repo.Exec(ctx, repoPath, argv, out)
+				results[i] = execResult{
+					repoName:  repoName,
+					exitCode:  repo.ExitCode(err),
+					cancelled: ctx.Err() != nil,
+					err:       err,
+				}
+
+				if prefix {
+					lines.Flush()
+					return
+				}
+				outMu.Lock()
+				defer outMu.Unlock()
+				fmt.Fprintf(r.out, "==> %s <==\n", repoName)
+				r.out.Write(buffer.Bytes())
+				if buffer.Len() > 0 && !bytes.HasSuffix(buffer.Bytes(), []byte("\n")) {
+					fmt.Fprintln(r.out)
+				}
+				fmt.Fprintln(r.out)
+			},
+		})
+	}
+
+	r.scheduler.Run(ctx, jobList)
+
+	successCount := 0
+	failCount := 0
+	cancelCount := 0
+
+	fmt.Fprintln(r.out, "\nExec Results:")
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+
+	for _, result := range results {
+		switch {
+		case result.cancelled:
+			cancelCount++
+			fmt.Fprintf(r.out, "⊘ %s: Cancelled\n", result.repoName)
+		case result.err == nil:
+			successCount++
+			fmt.Fprintf(r.out, "✓ %s: exit 0\n", result.repoName)
+		case result.exitCode > 0:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: exit %d\n", result.repoName, result.exitCode)
+		default:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: Failed - %v\n", result.repoName, result.err)
+		}
+	}
+
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+	fmt.Fprintf(r.out, "Summary: %s\n", summaryText(successCount, failCount, cancelCount, 0))
+
+	if cancelCount > 0 {
+		return fmt.Errorf("exec interrupted: %w", context.Canceled)
+	}
+	if failCount > 0 {
+		return fmt.Errorf("command failed in %d repositories", failCount)
+	}
+	return nil
+}
+
+// prefixWriter writes complete lines to out, each preceded by prefix, holding
+// back a partial line until it is completed or flushed
+type prefixWriter struct {
+	mu      *sync.Mutex
+	out     io.Writer
+	prefix  string
+	partial []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.writeLine(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
+	}
+}
+
+// Flush writes any partial last line
+func (w *prefixWriter) Flush() {
+	if len(w.partial) > 0 {
+		w.writeLine(append(w.partial, '\n'))
+		w.partial = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	io.WriteString(w.out, w.prefix)
+	w.out.Write(line)
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"get-repo/internal/jobs"
+	"get-repo/internal/repo"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormat selects what Export writes
+type ExportFormat int
+
+const (
+	ExportURLs     ExportFormat = iota // One URL per line, as read by "get-repo -f"
+	ExportManifest                     // YAML manifest, as read by sync
+	ExportJSON                         // JSON manifest, as read by sync
+)
+
+// ExportFormatFor picks the format a file name suggests: a manifest for
+// ".yaml" and ".yml", JSON for ".json" and a URL list for anything else
+func ExportFormatFor(file string) ExportFormat {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return ExportManifest
+	case ".json":
+		return ExportJSON
+	default:
+		return ExportURLs
+	}
+}
+
+// exportEntry is one repository being exported
+type exportEntry struct {
+	name  string
+	entry repo.ManifestRepo
+	err   error
+}
+
+// Export writes the origin remotes of the selected repositories, or of all
+// repositories when the selector is empty, so the workspace can be recreated
+// with "get-repo -f" or sync. With refs the checked out branch, or the commit
+// when detached, is recorded too. The result goes to file, or to the output
+// when file is empty; an existing file is only replaced with force.
+func (r *Runner) Export(ctx context.Context, sel repo.Selector, format ExportFormat, refs bool, file string, force bool) error {
+	repoNames, err := r.manager.Select(sel)
+	if err != nil {
+		return err
+	}
+	repoNames = r.exportable(repoNames)
+	if len(repoNames) == 0 {
+		return fmt.Errorf("no repositories to export")
+	}
+	if file != "" && !force {
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", file)
+		}
+	}
+
+	entries := make([]exportEntry, len(repoNames))
+	var jobList []jobs.Job
+	for i, repoName := range repoNames {
+		entries[i] = exportEntry{name: repoName}
+		jobList = append(jobList, jobs.Job{
+			Run: func(ctx context.Context) {
+				entries[i].entry, entries[i].err = r.exportRepo(ctx, repoName, refs)
+			},
+		})
+	}
+
+	// Export only reads local repositories, so per-host limits do not apply
+	jobs.NewScheduler(r.scheduler.Limit(), nil).Run(ctx, jobList)
+	if ctx.Err() != nil {
+		return fmt.Errorf("export interrupted: %w", ctx.Err())
+	}
+
+	var manifest repo.Manifest
+	failCount := 0
+	for _, e := range entries {
+		if e.err != nil {
+			failCount++
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", e.name, e.err)
+			continue
+		}
+		manifest.Repos = append(manifest.Repos, e.entry)
+	}
+
+	data, err := encodeExport(manifest, format)
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		if _, err := r.out.Write(data); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	} else {
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		fmt.Fprintf(r.out, "Exported %d repositories to %s\n", len(manifest.Repos), file)
+	}
+
+	if failCount > 0 {
+		return fmt.Errorf("%d repositories could not be exported", failCount)
+	}
+	return nil
+}
+
+// exportable drops submodules, which cloning their superproject brings back,
+// and linked worktrees, which share the remote of their main checkout
+func (r *Runner) exportable(names []string) []string {
+	var kept []string
+	for _, name := range names {
+		switch kind, _ := repo.DetectKind(r.manager.GetFullPath(name)); kind {
+		case repo.KindSubmodule, repo.KindWorktree:
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept
+}
+
+// exportRepo describes a checkout as a manifest entry. The path is only set
+// when the layout would put the remote somewhere else.
+func (r *Runner) exportRepo(ctx context.Context, name string, refs bool) (repo.ManifestRepo, error) {
+	repoPath := r.manager.GetFullPath(name)
+	remoteURL, err := r.git.GetRemoteURL(ctx, repoPath)
+	if err != nil {
+		return repo.ManifestRepo{}, fmt.Errorf("no origin remote")
+	}
+	remote, err := repo.ResolveURL(remoteURL)
+	if err != nil {
+		return repo.ManifestRepo{}, fmt.Errorf("unsupported remote %s", remoteURL)
+	}
+
+	entry := repo.ManifestRepo{URL: remoteURL, Tags: repo.Tags().TagsOf(name)}
+	if checkout := filepath.ToSlash(name); remote.ClonePath() != checkout {
+		entry.Path = checkout
+	}
+
+	if refs {
+		status, err := r.git.RepoStatus(ctx, repoPath)
+		if err != nil {
+			return repo.ManifestRepo{}, err
+		}
+		entry.Ref = status.Branch
+		if status.Detached {
+			entry.Ref = status.Commit
+		}
+	}
+	return entry, nil
+}
+
+// encodeExport renders the exported repositories in the given format
+func encodeExport(manifest repo.Manifest, format ExportFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case ExportManifest:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(manifest); err != nil {
+			return nil, fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		encoder.Close()
+	case ExportJSON:
+		if manifest.Repos == nil {
+			manifest.Repos = []repo.ManifestRepo{}
+		}
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	default:
+		// Paths and tags have no place in the URL list; refs become options
+		for _, entry := range manifest.Repos {
+			buf.WriteString(entry.URL)
+			if entry.Ref != "" {
+				buf.WriteString(" --ref " + entry.Ref)
+			}
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
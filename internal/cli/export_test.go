@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"get-repo/internal/repo"
+)
+
+func TestExportLeavesOutSubmodules(t *testing.T) {
+	r, base, out := testRunner(t)
+	app := superproject(t, base, "app", "https://github.com/me/app", "https://github.com/me/lib")
+	gitIn(t, app, "worktree", "add", "-q", "../app-wip")
+
+	if err := r.Export(context.Background(), repo.Selector{}, ExportURLs, false, "", false); err != nil {
+		t.Fatalf("Export: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(out.String()); got != "https://github.com/me/app" {
+		t.Errorf("Export wrote %q, want only the superproject", got)
+	}
+}
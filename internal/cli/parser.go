@@ -2,18 +2,27 @@ package cli
 
 import (
 	"fmt"
+	"get-repo/internal/repo"
+	"strconv"
 	"strings"
 )
 
 // Command represents a parsed command
 type Command struct {
-	Type       CommandType
-	Args       []string
-	Flags      map[string]bool
-	IsURL      bool
-	URLToClone string
-	CloneURLs  []string // For bulk clone
-	CloneFile  string   // File path for bulk clone from file
+	Type         CommandType
+	Args         []string
+	Flags        map[string]bool
+	IsURL        bool
+	URLToClone   string
+	CloneURLs    []string          // For bulk clone
+	CloneFile    string            // File of URLs to clone (-f with clone)
+	ManifestFile string            // Manifest to sync (-f with sync)
+	OutputFile   string            // File to export to (-f with export, empty = stdout)
+	Jobs         int               // Max concurrent git operations (0 = config default)
+	Clone        repo.CloneOptions // --ref, --depth, --filter, --single-branch, --sparse
+	Strategy     string            // --ff-only, --rebase or --autostash (empty = config default)
+	Exec         []string          // Command after "--" for exec
+	Select       repo.Selector     // Repository patterns, --host, --owner, --root, --not and --all
 }
 
 // CommandType represents the type of command
@@ -29,6 +38,18 @@ const (
 	CommandVersion
 	CommandInteractive
 	CommandCompletion
+	CommandProviders
+	CommandRelocate
+	CommandPath
+	CommandUnshallow
+	CommandFetch
+	CommandStatus
+	CommandExec
+	CommandTag
+	CommandUntag
+	CommandTags
+	CommandSync
+	CommandExport
 )
 
 // ParseArgs parses command line arguments
@@ -48,6 +69,8 @@ func ParseArgs(args []string) (*Command, error) {
 	// Process flags and collect remaining args
 	var remainingArgs []string
 	skipNext := false
+	file := "" // -f means a different file to each command, see below
+	hasExec := false
 
 	for i, arg := range args {
 		if skipNext {
@@ -55,6 +78,13 @@ func ParseArgs(args []string) (*Command, error) {
 			continue
 		}
 
+		// Everything after "--" is the command for exec
+		if arg == "--" {
+			cmd.Exec = args[i+1:]
+			hasExec = true
+			break
+		}
+
 		switch arg {
 		case "-i", "--interactive":
 			cmd.Type = CommandInteractive
@@ -69,19 +99,62 @@ func ParseArgs(args []string) (*Command, error) {
 			cmd.Flags["force"] = true
 		case "--cd":
 			cmd.Flags["cd"] = true
+		case "--dry-run":
+			cmd.Flags["dry-run"] = true
+		case "--pull":
+			cmd.Flags["pull"] = true
+		case "--prune":
+			cmd.Flags["prune"] = true
+		case "--dirty", "--behind", "--unpushed", "--json", "--prefix", "--manifest", "--refs", "--cached", "--refresh":
+			cmd.Flags[strings.TrimPrefix(arg, "--")] = true
+		case "--ff-only", "--rebase", "--autostash":
+			cmd.Strategy = strings.TrimPrefix(arg, "--")
 		case "-f", "--file":
 			if i+1 < len(args) {
-				cmd.CloneFile = args[i+1]
+				file = args[i+1]
 				skipNext = true
 			} else {
 				return nil, fmt.Errorf("--file requires a file path")
 			}
+		case "-j", "--jobs":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--jobs requires a number")
+			}
+			jobs, err := strconv.Atoi(args[i+1])
+			if err != nil || jobs < 1 {
+				return nil, fmt.Errorf("--jobs requires a positive number, got %q", args[i+1])
+			}
+			cmd.Jobs = jobs
+			skipNext = true
 		default:
-			remainingArgs = append(remainingArgs, arg)
+			used, err := repo.ParseSelectorFlag(args[i:], &cmd.Select)
+			if err != nil {
+				return nil, err
+			}
+			if used == 0 {
+				used, err = repo.ParseCloneFlag(args[i:], &cmd.Clone)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if used == 0 {
+				remainingArgs = append(remainingArgs, arg)
+			}
+			skipNext = used == 2
 		}
 	}
 
+	// Only exec runs a command; elsewhere it would be silently ignored
+	if hasExec && (len(remainingArgs) == 0 || remainingArgs[0] != "exec") {
+		return nil, fmt.Errorf("only exec takes a command after --, e.g. get-repo exec -- git status -s")
+	}
+
 	if len(remainingArgs) == 0 {
+		// "get-repo -f repos.txt" on its own clones from the file
+		if file != "" {
+			cmd.Type = CommandClone
+			cmd.CloneFile = file
+		}
 		// No arguments after flags - default to interactive mode
 		return cmd, nil
 	}
@@ -93,10 +166,11 @@ func ParseArgs(args []string) (*Command, error) {
 	if isGitURL(firstArg) {
 		cmd.Type = CommandClone
 		cmd.IsURL = true
+		cmd.CloneFile = file
 		// Collect all URLs for bulk clone
 		for _, arg := range remainingArgs {
 			if isGitURL(arg) {
-				cmd.CloneURLs = append(cmd.CloneURLs, arg)
+				cmd.CloneURLs = appendUniqueURL(cmd.CloneURLs, arg)
 			}
 		}
 		// Keep single URL for backward compatibility
@@ -110,29 +184,77 @@ func ParseArgs(args []string) (*Command, error) {
 	switch firstArg {
 	case "list":
 		cmd.Type = CommandList
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[1:]...)
 	case "update":
 		cmd.Type = CommandUpdate
-		if len(remainingArgs) > 1 {
-			cmd.Args = remainingArgs[1:]
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[1:]...)
+	case "fetch":
+		cmd.Type = CommandFetch
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[1:]...)
+	case "status":
+		cmd.Type = CommandStatus
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[1:]...)
+	case "exec":
+		cmd.Type = CommandExec
+		if len(cmd.Exec) == 0 {
+			return nil, fmt.Errorf("exec requires a command after --, e.g. get-repo exec -- git status -s")
 		}
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[1:]...)
 	case "remove":
 		cmd.Type = CommandRemove
-		if len(remainingArgs) > 1 {
-			cmd.Args = remainingArgs[1:]
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[1:]...)
+	case "tag", "untag":
+		cmd.Type = CommandTag
+		if firstArg == "untag" {
+			cmd.Type = CommandUntag
+		}
+		if len(remainingArgs) < 2 {
+			return nil, fmt.Errorf("%s requires a tag name", firstArg)
 		}
+		cmd.Args = remainingArgs[1:2]
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[2:]...)
+	case "tags":
+		cmd.Type = CommandTags
 	case "clone":
 		cmd.Type = CommandClone
+		cmd.CloneFile = file
 		// Collect all URLs after 'clone' command
 		if len(remainingArgs) > 1 {
 			for _, arg := range remainingArgs[1:] {
 				if isGitURL(arg) {
-					cmd.CloneURLs = append(cmd.CloneURLs, arg)
+					cmd.CloneURLs = appendUniqueURL(cmd.CloneURLs, arg)
 				}
 			}
 			if len(cmd.CloneURLs) > 0 {
 				cmd.URLToClone = cmd.CloneURLs[0]
 			}
 		}
+	case "sync":
+		cmd.Type = CommandSync
+		cmd.ManifestFile = file
+		if cmd.ManifestFile == "" {
+			return nil, fmt.Errorf("sync requires a manifest, e.g. get-repo sync -f workspace.yaml")
+		}
+	case "export":
+		cmd.Type = CommandExport
+		cmd.OutputFile = file
+		cmd.Select.Patterns = append(cmd.Select.Patterns, remainingArgs[1:]...)
+	case "relocate":
+		cmd.Type = CommandRelocate
+	case "unshallow":
+		cmd.Type = CommandUnshallow
+		if len(remainingArgs) < 2 {
+			return nil, fmt.Errorf("unshallow requires at least one repository")
+		}
+		cmd.Args = remainingArgs[1:]
+	case "path":
+		cmd.Type = CommandPath
+		if len(remainingArgs) != 2 {
+			return nil, fmt.Errorf("path requires exactly one URL or repository name")
+		}
+		cmd.Args = remainingArgs[1:]
+	case "providers":
+		cmd.Type = CommandProviders
 	case "completion":
 		cmd.Type = CommandCompletion
 		if len(remainingArgs) > 1 {
@@ -145,37 +267,46 @@ func ParseArgs(args []string) (*Command, error) {
 	return cmd, nil
 }
 
-// isGitURL checks if a string looks like a git URL
+// isGitURL checks if a string looks like a git URL or short notation
 func isGitURL(s string) bool {
-	// Check for short notation (anything with : that's not a protocol)
-	if strings.Contains(s, ":") && !strings.Contains(s, "://") && !strings.HasPrefix(s, "git@") {
-		// Ensure it's not a Windows path (C:\...)
-		if len(s) > 2 && s[1] == ':' && s[2] == '\\' {
-			return false
-		}
-		// Check if it has the pattern prefix:path
-		colonIndex := strings.Index(s, ":")
-		if colonIndex > 0 && colonIndex < len(s)-1 {
-			return true
-		}
+	// Ensure it's not a Windows path (C:\...)
+	if len(s) > 2 && s[1] == ':' && s[2] == '\\' {
+		return false
 	}
 
-	// HTTP(S) URLs
-	if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
-		return true
+	remote, err := repo.ResolveURL(s)
+	if err != nil {
+		return false
 	}
 
-	// SSH URLs
-	if strings.HasPrefix(s, "git@") {
-		return true
+	// Plain filesystem paths are only taken for bare repositories ("*.git"),
+	// so relative repository names given to commands are not mistaken for them
+	if remote.Local && !strings.Contains(s, "://") {
+		path, _ := repo.SplitRef(s)
+		return strings.HasSuffix(strings.TrimRight(path, "/\\"), ".git")
 	}
 
-	// SCP-style URLs (e.g., user@host:path)
-	if strings.Contains(s, "@") && strings.Contains(s, ":") {
-		return true
+	return true
+}
+
+// appendUniqueURL adds url to urls unless an equivalent URL is already there
+func appendUniqueURL(urls []string, url string) []string {
+	key := urlKey(url)
+	for _, existing := range urls {
+		if urlKey(existing) == key {
+			return urls
+		}
 	}
+	return append(urls, url)
+}
 
-	return false
+// urlKey identifies the repository a URL refers to, or the URL itself if it
+// does not parse
+func urlKey(url string) string {
+	if remote, err := repo.ResolveURL(url); err == nil {
+		return remote.Key()
+	}
+	return url
 }
 
 // NeedsInteractiveTUI determines if the command should launch the TUI
@@ -184,16 +315,46 @@ func (c *Command) NeedsInteractiveTUI() bool {
 	case CommandNone, CommandInteractive:
 		return true
 	case CommandUpdate, CommandRemove:
-		// Launch TUI if no specific repo specified
-		return len(c.Args) == 0
+		// Launch TUI if no repositories were selected
+		return c.Select.IsEmpty()
 	default:
 		return false
 	}
 }
 
-// GetHelpText returns the help text
+// GetHelpText returns the help text, including any configured providers
 func GetHelpText() string {
-	return `get-repo - A beautiful TUI for managing git repositories
+	text := helpText
+	if custom := customProviderHelp(); custom != "" {
+		text = strings.Replace(text, "\nOptions:", custom+"\nOptions:", 1)
+	}
+	return text
+}
+
+// customProviderHelp lists the providers declared in the configuration
+func customProviderHelp() string {
+	var lines []string
+	for _, p := range repo.Providers() {
+		if !p.Custom {
+			continue
+		}
+		example := "user/repo"
+		if p.DefaultOwner != "" {
+			example = "repo"
+		}
+		line := fmt.Sprintf("    %-25s → %s", p.Name+":"+example, p.Expand(example))
+		if len(p.Aliases) > 0 {
+			line += fmt.Sprintf(" (also %s:)", strings.Join(p.Aliases, ":, "))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n  Configured providers:\n" + strings.Join(lines, "\n") + "\n"
+}
+
+const helpText = `get-repo - A beautiful TUI for managing git repositories
 
 Usage:
   get-repo                        Launch interactive TUI
@@ -201,18 +362,38 @@ Usage:
   get-repo <url1> <url2> ...      Clone multiple repositories
   get-repo -f <file>              Clone repositories from file
   get-repo clone <url1> <url2>    Clone multiple repositories
-  get-repo list                   List all repositories
+  get-repo list [<select>]        List all (or the selected) repositories
   get-repo update                 Launch TUI in update mode
-  get-repo update <repo>          Update specific repository
+  get-repo update <select>        Update the selected repositories
+  get-repo fetch [<select>]       Fetch (all) repositories and show ahead/behind counts
+  get-repo status [<select>]      Show branch, changes, stashes and ahead/behind of (all) repositories
+  get-repo exec [<select>] -- <command>  Run a command in (all) repositories
   get-repo remove                 Launch TUI in remove mode
-  get-repo remove <repo> [--force] Remove specific repository
+  get-repo remove <select> [--force] Remove the selected repositories
+  get-repo sync -f <manifest> [--prune]  Clone, update and pin repositories listed in a manifest
+  get-repo export [<select>] [-f <file>]  Write the remotes of (all) repositories as a URL list or manifest
+  get-repo tag <tag> <select>     Tag the selected repositories (select them with @tag)
+  get-repo untag <tag> <select>   Remove a tag from the selected repositories
+  get-repo tags                   List tags and configured groups
+  get-repo path <url>             Print the local checkout of a URL (no network access)
+  get-repo unshallow <repo>...    Convert shallow/partial/single-branch clones to full
+  get-repo relocate [--dry-run]   Move checkouts to match the configured layout
+  get-repo providers              List shorthand prefixes and their hosts
   get-repo completion <shell>     Generate shell completion scripts
 
 URL Format:
   Full URLs:
     https://github.com/user/repo
     git@github.com:user/repo.git
-  
+    ssh://git@host:2222/group/subgroup/repo
+    git://host/repo, file:///srv/git/repo.git
+
+  Browser links (.../tree/main/pkg, /blob/, /commit/, /pull/, /-/merge_requests/)
+  clone the repository at the linked ref; --cd prints the linked directory.
+
+  Every form of the same remote clones to the same place (<host>/<path>),
+  so duplicates are skipped. Local repositories go under local/.
+
   Short notation (fuzzy matching):
     gh:user/repo              → https://github.com/user/repo
     gl:user/repo              → https://gitlab.com/user/repo
@@ -226,13 +407,60 @@ URL Format:
     gitl:user/repo            → https://gitlab.com/user/repo
     bit:user/repo             → https://bitbucket.org/user/repo
 
+  Custom providers (config "providers") add prefixes for self-hosted servers:
+    {"name": "corp", "host": "git.corp.example", "protocol": "ssh", "default_owner": "team"}
+    corp:service              → git@git.corp.example:team/service
+
+  URL rewrites (config "url_rewrites") switch protocol per host, e.g. SSH:
+    {"host": "github.com", "protocol": "ssh"}
+    gh:user/repo              → git@github.com:user/repo, path unchanged
+
+  Submodules are cloned recursively and updated after pulls (config "submodules"
+  turns this off globally or for some repositories).
+
+  Clone paths follow the config "layout" template (default {{.Host}}/{{.Owner}}/{{.Repo}});
+  run "get-repo relocate" after changing it to move existing checkouts.
+
+Selecting repositories (<select>):
+  github.com/user/repo      A repository name as shown by list, or a folder of them
+  'github.com/myorg/*'      Glob on the name; without a slash, on any part ('*-archive')
+  're:^gitlab\.com/.*-svc$'  Regular expression on the name
+  @payments                 Repositories tagged "payments" or in that config "groups" entry
+  '!pattern', --not <pat>   Exclude what the pattern matches
+  --host <glob>             Only repositories on matching hosts
+  --owner <glob>            Only repositories of matching owners (groups match subgroups)
+  --root <name>             Only repositories in a configured root
+  --all                     Every repository (update and remove need a selection)
+
 Options:
   -i, --interactive    Force interactive TUI mode
   -h, --help          Show this help message
   -v, --version       Show version information
   -f, --file <path>   Read repository URLs from file
+  -j, --jobs <n>      Run at most n git operations at once (default: config "jobs", up to 8)
   --force             Skip confirmation prompts
   --cd                Output repository path after clone/update (use with: cd $(get-repo <url> --cd))
+  --depth <n>         Shallow clone with the last n commits
+  --filter <spec>     Partial clone, e.g. blob:none (no file contents) or tree:0
+  --single-branch     Only fetch the branch being checked out
+  --sparse <dirs>     Sparse checkout of comma-separated directories (cone mode)
+  --pull              Fast-forward repositories that are already cloned
+  --ff-only           Update by fast-forwarding only (default: config "update_strategy")
+  --rebase            Update by rebasing local commits onto upstream
+  --autostash         Rebase, stashing uncommitted changes around it
+  --dry-run           Show what relocate would move without moving anything
+  --prune             sync: remove repositories not in the manifest (never ones with local work)
+  --dirty             status: only repositories with uncommitted or untracked files
+  --behind            status: only repositories behind their upstream (as of the last fetch)
+  --unpushed          status: only repositories with unpushed commits or no upstream
+  --json              status: print JSON instead of a table; export: write a JSON manifest
+  --manifest          export: write a YAML manifest for sync (default with -f *.yaml)
+  --refs              export: record the checked out branch, or commit when detached
+  --cached            list: answer from the repository index without checking the disk
+  --refresh           list: rescan the disk and rebuild the repository index
+  --prefix            exec: stream output prefixed with the repository name
+  --ref <ref>         Clone at a branch, tag or commit (same as url@ref or url#ref)
+  --root <name>       clone, sync: clone into this config "roots" entry, whatever its match rules
 
 Completion:
   get-repo completion bash        Generate bash completion
@@ -243,10 +471,33 @@ Examples:
   get-repo gh:dardevelin/get-repo
   get-repo gh:user/repo1 gitlab:user/repo2
   cd $(get-repo gh:golang/go --cd)
+  cd $(get-repo gh:golang/go --cd --pull)
+  cd $(get-repo path gh:golang/go)
+  get-repo gh:user/repo@v1.4.2
+  get-repo gh:user/repo#feature-x
+  get-repo gh:user/repo --ref 3f2a9c1
   get-repo -f repos.txt
+  get-repo -f repos.txt --jobs 4
+  get-repo gh:org/monorepo --filter=blob:none --sparse services/api,libs
+  get-repo unshallow github.com/org/monorepo
   get-repo list
+  get-repo fetch
+  get-repo status --dirty
+  get-repo fetch && get-repo status --behind --json
+  get-repo exec -- git gc --auto
+  get-repo exec --prefix -- 'git log -1 --format=%cr'
   cd $(get-repo update my-project --cd)
+  get-repo update github.com/user/repo --autostash
+  get-repo update --owner myorg --not '*-archive'
+  get-repo status --host gitlab.com --dirty
+  get-repo update --all
+  get-repo tag payments 'github.com/acme/pay-*' github.com/acme/ledger
+  get-repo update @payments
+  get-repo exec @payments '!@archived' -- make test
   get-repo remove old-project --force
+  get-repo relocate --dry-run
+  get-repo sync -f workspace.yaml
+  get-repo export --refs -f workspace.yaml
   
   # Short notation examples:
   get-repo gh:golang/go
@@ -255,14 +506,23 @@ Examples:
   
   # File format for -f option (repos.txt):
   # Comments start with #
-  # One URL per line (supports short notation)
+  # One URL per line (supports short notation), optionally with clone options
   gh:user/repo1
   gitlab:user/repo2
   https://github.com/user/repo3
+  gh:org/monorepo --depth 1 --sparse services/api
+  
+  # Manifest for sync (workspace.yaml, or JSON with the same fields):
+  # repos:
+  #   - url: gh:org/api
+  #     ref: v2.1.0                 # Branch, tag or commit to keep checked out
+  #     depth: 1                    # Also filter, single_branch, sparse
+  #     path: work/api              # Instead of the layout's path
+  #     tags: [payments]
+  #     hooks: {post_clone: make setup, post_update: make deps}
   
   # Install bash completion
   get-repo completion bash > ~/.bash_completion.d/get-repo
   
   # Install zsh completion
   get-repo completion zsh > ~/.oh-my-zsh/completions/_get-repo`
-}
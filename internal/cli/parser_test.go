@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseArgsFile(t *testing.T) {
+	tests := []struct {
+		args                        string
+		typ                         CommandType
+		clone, manifest, outputFile string
+	}{
+		{args: "-f repos.txt", typ: CommandClone, clone: "repos.txt"},
+		{args: "clone -f repos.txt", typ: CommandClone, clone: "repos.txt"},
+		{args: "gh:user/repo --file repos.txt", typ: CommandClone, clone: "repos.txt"},
+		{args: "sync -f workspace.yaml --prune", typ: CommandSync, manifest: "workspace.yaml"},
+		{args: "export github.com -f repos.json", typ: CommandExport, outputFile: "repos.json"},
+		{args: "export", typ: CommandExport},
+	}
+
+	for _, tt := range tests {
+		cmd, err := ParseArgs(strings.Fields(tt.args))
+		if err != nil {
+			t.Errorf("ParseArgs(%q): %v", tt.args, err)
+			continue
+		}
+		if cmd.Type != tt.typ || cmd.CloneFile != tt.clone || cmd.ManifestFile != tt.manifest || cmd.OutputFile != tt.outputFile {
+			t.Errorf("ParseArgs(%q) = type %v clone %q manifest %q output %q, want %v %q %q %q", tt.args,
+				cmd.Type, cmd.CloneFile, cmd.ManifestFile, cmd.OutputFile, tt.typ, tt.clone, tt.manifest, tt.outputFile)
+		}
+	}
+
+	for _, args := range []string{"sync", "export -f", "clone --file"} {
+		if _, err := ParseArgs(strings.Fields(args)); err == nil {
+			t.Errorf("ParseArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestParseArgsExec(t *testing.T) {
+	cmd, err := ParseArgs(strings.Fields("exec github.com/me -- git status -s"))
+	if err != nil {
+		t.Fatalf("ParseArgs: %v", err)
+	}
+	if cmd.Type != CommandExec || strings.Join(cmd.Exec, " ") != "git status -s" || len(cmd.Select.Patterns) != 1 {
+		t.Errorf("ParseArgs = type %v exec %q patterns %q", cmd.Type, cmd.Exec, cmd.Select.Patterns)
+	}
+
+	for _, args := range []string{"update -- foo", "list --", "-- git status", "gh:user/repo -- make", "exec --"} {
+		if _, err := ParseArgs(strings.Fields(args)); err == nil {
+			t.Errorf("ParseArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
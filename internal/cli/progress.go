@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"get-repo/internal/repo"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	progressBarWidth    = 20
+	progressRedrawDelay = 100 * time.Millisecond
+)
+
+// lineState tracks where a repository is in its lifecycle on the display
+type lineState int
+
+const (
+	lineWaiting lineState = iota
+	lineActive
+	lineFinished
+	linePrinted
+)
+
+// progressDisplay renders live status lines for repositories being processed.
+// Running repositories keep a line that updates in place; finished ones are
+// printed once above them, so the live area stays as small as the number of
+// concurrent jobs. When the output is not a terminal it stays silent, so piped
+// output and command substitution (--cd) only see the final results.
+type progressDisplay struct {
+	mu       sync.Mutex
+	out      *os.File
+	live     bool
+	labels   []string
+	status   []string
+	state    []lineState
+	order    []int // Indices in the order they became active
+	done     int
+	width    int
+	rendered int
+	lastDraw time.Time
+}
+
+// newProgressDisplay creates a display with one line per label, written to stderr
+func newProgressDisplay(labels []string) *progressDisplay {
+	width := 0
+	for _, label := range labels {
+		if len(label) > width {
+			width = len(label)
+		}
+	}
+
+	return &progressDisplay{
+		out:    os.Stderr,
+		live:   isTerminal(os.Stderr),
+		labels: labels,
+		status: make([]string, len(labels)),
+		state:  make([]lineState, len(labels)),
+		width:  width,
+	}
+}
+
+// Set replaces the status text of a running line and redraws immediately
+func (d *progressDisplay) Set(index int, status string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.activate(index)
+	d.status[index] = status
+	d.draw()
+}
+
+// Finish records the final status of a line and redraws immediately
+func (d *progressDisplay) Finish(index int, status string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.activate(index)
+	if d.state[index] == lineActive {
+		d.state[index] = lineFinished
+		d.done++
+	}
+	d.status[index] = status
+	d.draw()
+}
+
+// Progress returns a callback that renders git progress on the given line
+func (d *progressDisplay) Progress(index int) repo.ProgressFunc {
+	return func(p repo.Progress) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.activate(index)
+		d.status[index] = renderProgressBar(p)
+		// Git reports progress many times per second; throttle redraws
+		if time.Since(d.lastDraw) >= progressRedrawDelay {
+			d.draw()
+		}
+	}
+}
+
+// Close draws the final state and releases the live area
+func (d *progressDisplay) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.draw()
+	d.rendered = 0
+}
+
+// activate moves a waiting line into the live area. The caller must hold d.mu.
+func (d *progressDisplay) activate(index int) {
+	if d.state[index] == lineWaiting {
+		d.state[index] = lineActive
+		d.order = append(d.order, index)
+	}
+}
+
+// draw repaints the live area in place. The caller must hold d.mu.
+func (d *progressDisplay) draw() {
+	if !d.live {
+		return
+	}
+
+	var b strings.Builder
+	if d.rendered > 0 {
+		fmt.Fprintf(&b, "\033[%dA\033[J", d.rendered)
+	}
+
+	// Finished lines scroll off above the live area
+	for _, i := range d.order {
+		if d.state[i] == lineFinished {
+			fmt.Fprintf(&b, "%-*s  %s\n", d.width, d.labels[i], d.status[i])
+			d.state[i] = linePrinted
+		}
+	}
+
+	d.rendered = 0
+	for _, i := range d.order {
+		if d.state[i] == lineActive {
+			fmt.Fprintf(&b, "%-*s  %s\n", d.width, d.labels[i], d.status[i])
+			d.rendered++
+		}
+	}
+	if len(d.labels) > 1 {
+		fmt.Fprintf(&b, "[%d/%d done]\n", d.done, len(d.labels))
+		d.rendered++
+	}
+
+	fmt.Fprint(d.out, b.String())
+	d.lastDraw = time.Now()
+}
+
+// renderProgressBar formats a progress update as a text bar
+func renderProgressBar(p repo.Progress) string {
+	filled := int(p.Overall() * progressBarWidth)
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+	return fmt.Sprintf("[%s] %s", bar, p)
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
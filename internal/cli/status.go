@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"get-repo/internal/jobs"
+	"get-repo/internal/repo"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// StatusFilter limits the repositories reported by Status; all set
+// conditions must hold
+type StatusFilter struct {
+	Dirty    bool // Uncommitted changes or untracked files
+	Behind   bool // Upstream commits not pulled, as of the last fetch
+	Unpushed bool // Commits not on the upstream, or a branch without upstream
+}
+
+// matches reports whether a repository passes the filter
+func (f StatusFilter) matches(s repo.RepoStatus) bool {
+	if f.Dirty && !s.Dirty() {
+		return false
+	}
+	if f.Behind && s.Behind == 0 {
+		return false
+	}
+	if f.Unpushed && s.Ahead == 0 && (s.Upstream != "" || s.Branch == "" || s.LastCommit.IsZero() || s.Kind == repo.KindBare) {
+		return false
+	}
+	return true
+}
+
+// statusEntry is one repository in the status report
+type statusEntry struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+	repo.RepoStatus
+}
+
+// Status reports the branch, working tree changes, stashes, upstream distance
+// and last commit age of the selected repositories, or of all repositories
+// when the selector is empty. Repositories are inspected in parallel and only
+// local data is used, so behind counts are as of the last fetch.
+func (r *Runner) Status(ctx context.Context, sel repo.Selector, filter StatusFilter, jsonOutput bool) error {
+	repoNames, err := r.manager.Select(sel)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]statusEntry, len(repoNames))
+	var jobList []jobs.Job
+	for i, repoName := range repoNames {
+		entries[i] = statusEntry{Name: repoName, Path: r.manager.GetFullPath(repoName)}
+		jobList = append(jobList, jobs.Job{
+			Run: func(ctx context.Context) {
+				if !repo.IsGitRepository(entries[i].Path) {
+					entries[i].Error = "not a git repository"
+					return
+				}
+				status, err := r.git.RepoStatus(ctx, entries[i].Path)
+				if err != nil {
+					entries[i].Error = err.Error()
+					return
+				}
+				entries[i].RepoStatus = status
+			},
+		})
+	}
+
+	// Status only reads local repositories, so per-host limits do not apply
+	jobs.NewScheduler(r.scheduler.Limit(), nil).Run(ctx, jobList)
+	if ctx.Err() != nil {
+		return fmt.Errorf("status interrupted: %w", ctx.Err())
+	}
+
+	failCount := 0
+	var shown []statusEntry
+	for _, entry := range entries {
+		if entry.Error != "" {
+			failCount++
+			shown = append(shown, entry)
+			continue
+		}
+		if filter.matches(entry.RepoStatus) {
+			shown = append(shown, entry)
+		}
+	}
+
+	if jsonOutput {
+		if shown == nil {
+			shown = []statusEntry{}
+		}
+		data, err := json.MarshalIndent(shown, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+		fmt.Fprintln(r.out, string(data))
+	} else if len(shown) == 0 {
+		if len(repoNames) == 0 {
+			fmt.Fprintln(r.out, "No repositories found.")
+		} else {
+			fmt.Fprintln(r.out, "No repositories match.")
+		}
+	} else {
+		r.printStatusTable(shown)
+	}
+
+	if failCount > 0 {
+		return fmt.Errorf("%d repositories could not be inspected", failCount)
+	}
+	return nil
+}
+
+// printStatusTable writes one aligned row per repository
+func (r *Runner) printStatusTable(entries []statusEntry) {
+	w := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tBRANCH\tCHANGES\tSTASH\tUPSTREAM\tLAST COMMIT")
+	for _, e := range entries {
+		if e.Error != "" {
+			fmt.Fprintf(w, "%s\t✗ %s\t\t\t\t\n", e.Name, e.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			nameText(e.Name, e.Kind), branchText(e.RepoStatus), changesText(e.RepoStatus),
+			countText(e.Stashes), upstreamText(e.RepoStatus), ageText(e.LastCommit))
+	}
+	w.Flush()
+}
+
+// nameText shows a repository name with its kind unless it is a normal
+// checkout, e.g. "github.com/org/repo-wt (worktree)"
+func nameText(name string, kind repo.Kind) string {
+	if kind == repo.KindNormal {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, kind)
+}
+
+// branchText shows the checked out branch, or that HEAD is detached
+func branchText(s repo.RepoStatus) string {
+	if s.Detached {
+		return "(detached)"
+	}
+	return s.Branch
+}
+
+// changesText summarizes the working tree, e.g. "3 changed, 1 untracked"
+func changesText(s repo.RepoStatus) string {
+	if s.Kind == repo.KindBare {
+		return "no working tree"
+	}
+	var parts []string
+	if s.Changed > 0 {
+		parts = append(parts, fmt.Sprintf("%d changed", s.Changed))
+	}
+	if s.Untracked > 0 {
+		parts = append(parts, fmt.Sprintf("%d untracked", s.Untracked))
+	}
+	if len(parts) == 0 {
+		return "clean"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// upstreamText shows the distance to the upstream branch as a tracking badge
+func upstreamText(s repo.RepoStatus) string {
+	switch {
+	case s.Detached, s.Kind == repo.KindBare:
+		return "-"
+	case s.Upstream == "":
+		return "no upstream"
+	}
+	badge := repo.Tracking{Ahead: s.Ahead, Behind: s.Behind}.Badge()
+	if badge == "" {
+		return "up to date"
+	}
+	return badge
+}
+
+// countText shows a count, with "-" for zero to keep the table readable
+func countText(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
+
+// ageText shows how long ago t was in the largest whole unit, e.g. "3d"
+func ageText(t time.Time) string {
+	if t.IsZero() {
+		return "no commits"
+	}
+	age := time.Since(t)
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 30*24*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	case age < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(age.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("%dy", int(age.Hours()/(24*365)))
+	}
+}
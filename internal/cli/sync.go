@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"get-repo/internal/jobs"
+	"get-repo/internal/repo"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type syncResult struct {
+	repoPath     string
+	cloned       bool
+	success      bool
+	cancelled    bool
+	skipped      string
+	output       string
+	err          error
+	submoduleErr error
+}
+
+// Sync makes the workspace match a manifest: missing repositories are
+// cloned, existing ones updated or moved to their pinned ref, and hooks run
+// after each. Local repositories not in the manifest are reported, or with
+// prune removed unless they hold work that exists nowhere else.
+func (r *Runner) Sync(ctx context.Context, manifestFile string, prune, force bool) error {
+	manifest, err := repo.LoadManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+	if len(manifest.Repos) == 0 {
+		return fmt.Errorf("%s lists no repositories", manifestFile)
+	}
+
+	// LoadManifest has already resolved every entry
+	remotes := make([]repo.RemoteURL, len(manifest.Repos))
+	labels := make([]string, len(manifest.Repos))
+	for i, entry := range manifest.Repos {
+		remotes[i], labels[i], _ = entry.ResolveIn(r.root)
+	}
+
+	results := make([]syncResult, len(manifest.Repos))
+	display := newProgressDisplay(labels)
+
+	var jobList []jobs.Job
+	for i, entry := range manifest.Repos {
+		remote, clonePath := remotes[i], labels[i]
+		destination := r.manager.GetFullPath(clonePath)
+		// Anything the scheduler never starts was cancelled while queued
+		results[i] = syncResult{repoPath: clonePath, cancelled: true}
+
+		jobList = append(jobList, jobs.Job{
+			Host: remote.Host,
+			Run: func(ctx context.Context) {
+				exists, err := r.existingCheckout(ctx, remote, clonePath)
+				if err != nil {
+					display.Finish(i, "✗ already exists")
+					results[i] = syncResult{repoPath: clonePath, err: err}
+					return
+				}
+
+				var result repo.GitOperation
+				opts := entry.Options(remote)
+				hook := entry.Hooks.PostClone
+				display.Set(i, "starting")
+				switch {
+				case !exists:
+					result = r.git.Clone(ctx, remote.CloneURL(), destination, opts, display.Progress(i))
+				case opts.Ref != "":
+					hook = entry.Hooks.PostUpdate
+					result = r.git.Checkout(ctx, destination, opts.Ref, r.strategy, display.Progress(i))
+				default:
+					hook = entry.Hooks.PostUpdate
+					result = r.git.Pull(ctx, destination, r.strategy, display.Progress(i))
+				}
+
+				if result.Success && result.SubmoduleError == nil && hook != "" {
+					display.Set(i, "running hook")
+					if err := runHook(ctx, destination, hook); err != nil {
+						result = repo.GitOperation{Success: false, Error: err}
+					}
+				}
+				if result.Success {
+					r.git.RecordTracking(ctx, r.tracking, clonePath, destination)
+					r.manager.RecordFetch(clonePath)
+				}
+				display.Finish(i, statusText(result))
+				results[i] = syncResult{
+					repoPath:     clonePath,
+					cloned:       !exists,
+					success:      result.Success,
+					cancelled:    result.Cancelled(),
+					skipped:      result.SkipReason,
+					output:       result.Output,
+					err:          result.Error,
+					submoduleErr: result.SubmoduleError,
+				}
+			},
+		})
+	}
+
+	r.scheduler.Run(ctx, jobList)
+	display.Close()
+	r.saveTracking()
+	r.saveIndex()
+
+	// Tags are added to every checkout the manifest lists, even one that
+	// could not be updated this time
+	tags := repo.Tags()
+	wanted := make(map[string]bool)
+	for i, entry := range manifest.Repos {
+		wanted[filepath.FromSlash(labels[i])] = true
+		if r.manager.PathExists(labels[i]) {
+			for _, tag := range entry.Tags {
+				tags.Tag(tag, []string{labels[i]})
+			}
+		}
+	}
+	if err := tags.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	successCount := 0
+	failCount := 0
+	cancelCount := 0
+	skipCount := 0
+
+	fmt.Fprintln(r.out, "\nSync Results:")
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+
+	for _, result := range results {
+		switch {
+		case result.skipped != "":
+			skipCount++
+			fmt.Fprintf(r.out, "↷ %s: Skipped: %s\n", result.repoPath, skipText(result.skipped))
+		case result.success && result.submoduleErr != nil:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: Synced, but %v\n", result.repoPath, result.submoduleErr)
+		case result.success && result.cloned:
+			successCount++
+			fmt.Fprintf(r.out, "✓ %s: Cloned\n", result.repoPath)
+		case result.success:
+			successCount++
+			fmt.Fprintf(r.out, "✓ %s: Updated\n", result.repoPath)
+		case result.cancelled:
+			cancelCount++
+			fmt.Fprintf(r.out, "⊘ %s: Cancelled\n", result.repoPath)
+		default:
+			failCount++
+			fmt.Fprintf(r.out, "✗ %s: Failed - %v\n", result.repoPath, result.err)
+		}
+	}
+
+	fmt.Fprintln(r.out, strings.Repeat("-", 50))
+	fmt.Fprintf(r.out, "Summary: %s\n", summaryText(successCount, failCount, cancelCount, skipCount))
+
+	if cancelCount > 0 {
+		return fmt.Errorf("sync interrupted: %w", context.Canceled)
+	}
+
+	if err := r.pruneExtras(ctx, wanted, prune, force); err != nil {
+		return err
+	}
+	if failCount > 0 {
+		return fmt.Errorf("%d repositories could not be synced", failCount)
+	}
+	return nil
+}
+
+// pruneExtras lists the repositories that are not wanted and, with prune,
+// removes those without uncommitted changes, stashes or unpushed commits.
+// Submodules and repositories inside a wanted checkout belong to it and are
+// not extras. With a root given to SetRoot, other roots are left alone.
+func (r *Runner) pruneExtras(ctx context.Context, wanted map[string]bool, prune, force bool) error {
+	repos, err := r.manager.List()
+	if err != nil {
+		return fmt.Errorf("error scanning repositories: %w", err)
+	}
+
+	var extras []string
+	for _, rp := range repos {
+		if r.root != "" && repo.RootOf(rp.Name) != r.root {
+			continue
+		}
+		if rp.IsGitDir && rp.Kind != repo.KindSubmodule && !wanted[rp.Name] && !insideWanted(rp.Name, wanted) {
+			extras = append(extras, rp.Name)
+		}
+	}
+	if len(extras) == 0 {
+		return nil
+	}
+
+	if !prune {
+		fmt.Fprintf(r.out, "\nNot in the manifest (use --prune to remove):\n")
+		for _, name := range extras {
+			fmt.Fprintf(r.out, "  - %s\n", name)
+		}
+		return nil
+	}
+
+	// Work that exists nowhere else is never pruned
+	var removable []string
+	for _, name := range extras {
+		status, err := r.git.RepoStatus(ctx, r.manager.GetFullPath(name))
+		switch {
+		case err != nil:
+			fmt.Fprintf(r.out, "Keeping %s: %v\n", name, err)
+		case status.Dirty() || status.Stashes > 0:
+			fmt.Fprintf(r.out, "Keeping %s: uncommitted changes or stashes\n", name)
+		case status.Ahead > 0 || (!status.Detached && status.Upstream == "" && status.Kind != repo.KindBare):
+			fmt.Fprintf(r.out, "Keeping %s: unpushed commits\n", name)
+		default:
+			removable = append(removable, name)
+		}
+	}
+	if len(removable) == 0 {
+		return nil
+	}
+
+	if !force {
+		fmt.Fprintf(r.out, "\nRemove these repositories that are not in the manifest?\n")
+		for _, name := range removable {
+			fmt.Fprintf(r.out, "  - %s\n", name)
+		}
+		fmt.Fprint(r.out, "\nThis action cannot be undone. Continue? [y/N] ")
+
+		reader := bufio.NewReader(os.Stdin)
+		input, _ := reader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(input)) != "y" {
+			fmt.Fprintln(r.out, "Prune cancelled.")
+			return nil
+		}
+	}
+
+	var removed []string
+	defer func() {
+		r.forgetTags(removed)
+		r.saveIndex()
+	}()
+	for _, name := range removable {
+		if err := os.RemoveAll(r.manager.GetFullPath(name)); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+		r.manager.RecordRemoval(name)
+		removed = append(removed, name)
+		fmt.Fprintf(r.out, "Removed %s\n", name)
+	}
+	return nil
+}
+
+// insideWanted reports whether name is below one of the wanted checkouts
+func insideWanted(name string, wanted map[string]bool) bool {
+	for dir := filepath.Dir(name); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if wanted[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// runHook runs a manifest hook through the shell inside a repository.
+// Its output is only shown when it fails.
+func runHook(ctx context.Context, repoPath, command string) error {
+	var output bytes.Buffer
+	if err := repo.Exec(ctx, repoPath, []string{command}, &output); err != nil {
+		if code := repo.ExitCode(err); code > 0 {
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			if last := lines[len(lines)-1]; last != "" {
+				return fmt.Errorf("hook %q exited %d: %s", command, code, last)
+			}
+			return fmt.Errorf("hook %q exited %d", command, code)
+		}
+		return fmt.Errorf("hook %q: %w", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncPruneKeepsSubmodules(t *testing.T) {
+	r, base, out := testRunner(t)
+
+	origin := filepath.Join(t.TempDir(), "app.git")
+	app := superproject(t, base, "app", origin, "https://github.com/me/lib")
+	gitIn(t, base, "clone", "-q", "--bare", app, origin)
+	gitIn(t, app, "fetch", "-q", "origin")
+	gitIn(t, app, "branch", "-q", "--set-upstream-to", "origin/main")
+
+	manifest := filepath.Join(t.TempDir(), "workspace.yaml")
+	content := "repos:\n  - url: " + origin + "\n    path: app\n"
+	if err := os.WriteFile(manifest, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Sync(context.Background(), manifest, true, true); err != nil {
+		t.Fatalf("Sync: %v\n%s", err, out)
+	}
+	if strings.Contains(out.String(), "Removed") {
+		t.Errorf("sync pruned a repository of the manifest:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(app, "vendor", "lib", "README")); err != nil {
+		t.Errorf("submodule is gone after sync --prune: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"get-repo/internal/repo"
+	"os"
+)
+
+// Tag adds a tag to the repositories the selector picks
+func (r *Runner) Tag(tag string, sel repo.Selector) error {
+	if err := repo.ValidateTagName(tag); err != nil {
+		return err
+	}
+	if sel.IsEmpty() {
+		return fmt.Errorf("no repositories specified")
+	}
+	names, err := r.manager.Select(sel)
+	if err != nil {
+		return err
+	}
+
+	tags := repo.Tags()
+	added := tags.Tag(tag, names)
+	if err := tags.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "Tagged %d repositories with @%s (%d already were)\n", added, tag, len(names)-added)
+	return nil
+}
+
+// Untag removes a tag from the repositories the selector picks
+func (r *Runner) Untag(tag string, sel repo.Selector) error {
+	if sel.IsEmpty() {
+		return fmt.Errorf("no repositories specified (use --all to remove the tag everywhere)")
+	}
+	names, err := r.manager.Select(sel)
+	if err != nil {
+		return err
+	}
+
+	tags := repo.Tags()
+	removed := tags.Untag(tag, names)
+	if removed == 0 {
+		fmt.Fprintf(r.out, "None of the %d repositories has tag @%s\n", len(names), tag)
+		return nil
+	}
+	if err := tags.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "Removed @%s from %d repositories\n", tag, removed)
+	return nil
+}
+
+// ListTags prints every tag and configured group with the number of
+// repositories in it, tab separated for completion scripts
+func (r *Runner) ListTags() error {
+	names := repo.GroupNames()
+	if len(names) == 0 {
+		fmt.Fprintln(r.out, "No tags or groups defined.")
+		return nil
+	}
+
+	for _, name := range names {
+		// A group whose patterns match nothing is still worth listing
+		members, _ := r.manager.Select(repo.Selector{Patterns: []string{"@" + name}})
+		fmt.Fprintf(r.out, "@%s\t%d repositories\n", name, len(members))
+	}
+	return nil
+}
+
+// forgetTags drops removed repositories from their tags; failing to save
+// only leaves stale names behind, so it is not an error for the command
+func (r *Runner) forgetTags(names []string) {
+	tags := repo.Tags()
+	for _, name := range names {
+		tags.Forget(name)
+	}
+	if err := tags.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
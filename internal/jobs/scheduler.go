@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// DefaultConcurrency is used when no job limit is configured. Git operations
+// are mostly network bound, so a small multiple of the CPU count keeps the
+// pipe busy without tripping SSH MaxStartups or provider rate limits.
+var DefaultConcurrency = min(2*runtime.NumCPU(), 8)
+
+// AnyHost is the HostLimits key that applies to hosts without their own entry
+const AnyHost = "*"
+
+// Scheduler hands out execution slots for git operations in FIFO order,
+// bounded by a global concurrency limit and optional per-host caps.
+// A job whose host is saturated does not hold up jobs for other hosts.
+type Scheduler struct {
+	mu          sync.Mutex
+	limit       int
+	hostLimits  map[string]int
+	running     int
+	hostRunning map[string]int
+	queue       []*Ticket
+}
+
+// Ticket is a reserved place in the scheduler queue
+type Ticket struct {
+	scheduler *Scheduler
+	host      string
+	ready     chan struct{}
+	granted   bool
+	released  bool
+}
+
+// Job is a unit of work for Run
+type Job struct {
+	Host string // Host the job talks to, used for per-host limits
+	Run  func(ctx context.Context)
+}
+
+// NewScheduler creates a scheduler running at most limit jobs at once
+// (DefaultConcurrency if limit <= 0). hostLimits caps concurrent jobs per host;
+// the AnyHost key sets a cap for hosts not listed explicitly.
+func NewScheduler(limit int, hostLimits map[string]int) *Scheduler {
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	return &Scheduler{
+		limit:       limit,
+		hostLimits:  hostLimits,
+		hostRunning: make(map[string]int),
+	}
+}
+
+// Limit returns the global concurrency limit
+func (s *Scheduler) Limit() int {
+	return s.limit
+}
+
+// Enqueue reserves a place in the queue for a job talking to host.
+// Call it in submission order; slots are granted in that same order.
+func (s *Scheduler) Enqueue(host string) *Ticket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &Ticket{scheduler: s, host: host, ready: make(chan struct{})}
+	s.queue = append(s.queue, t)
+	s.dispatch()
+	return t
+}
+
+// Wait blocks until the ticket is granted a slot or ctx is done.
+// On error the ticket leaves the queue and Done must not be called.
+func (t *Ticket) Wait(ctx context.Context) error {
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s := t.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.granted {
+		// Granted while we were being cancelled; hand the slot back
+		s.release(t)
+	} else {
+		s.remove(t)
+	}
+	return ctx.Err()
+}
+
+// Done releases the slot held by the ticket
+func (t *Ticket) Done() {
+	s := t.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.release(t)
+}
+
+// Run executes jobs through the scheduler and waits for all of them.
+// Jobs still queued when ctx is cancelled are skipped.
+func (s *Scheduler) Run(ctx context.Context, jobs []Job) {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		ticket := s.Enqueue(job.Host)
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			if err := ticket.Wait(ctx); err != nil {
+				return
+			}
+			defer ticket.Done()
+			// A slot granted just as ctx was cancelled is not used either
+			if ctx.Err() != nil {
+				return
+			}
+			job.Run(ctx)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// hostLimit returns the cap for host, or 0 when unlimited
+func (s *Scheduler) hostLimit(host string) int {
+	if limit, ok := s.hostLimits[host]; ok {
+		return limit
+	}
+	return s.hostLimits[AnyHost]
+}
+
+// dispatch grants slots to queued tickets, oldest first. The caller must hold s.mu.
+func (s *Scheduler) dispatch() {
+	remaining := s.queue[:0]
+	for _, t := range s.queue {
+		limit := s.hostLimit(t.host)
+		if s.running < s.limit && (limit <= 0 || s.hostRunning[t.host] < limit) {
+			s.running++
+			s.hostRunning[t.host]++
+			t.granted = true
+			close(t.ready)
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+	s.queue = remaining
+}
+
+// release frees the slot of a granted ticket. The caller must hold s.mu.
+func (s *Scheduler) release(t *Ticket) {
+	if !t.granted || t.released {
+		return
+	}
+	t.released = true
+	s.running--
+	s.hostRunning[t.host]--
+	s.dispatch()
+}
+
+// remove drops a waiting ticket from the queue. The caller must hold s.mu.
+func (s *Scheduler) remove(t *Ticket) {
+	for i, queued := range s.queue {
+		if queued == t {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// granted reports whether the ticket has been given a slot
+func granted(t *Ticket) bool {
+	select {
+	case <-t.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestSchedulerGrantsInOrder(t *testing.T) {
+	s := NewScheduler(1, nil)
+	first, second, third := s.Enqueue("a"), s.Enqueue("b"), s.Enqueue("c")
+
+	if !granted(first) || granted(second) || granted(third) {
+		t.Fatalf("granted = %v %v %v, want only the first", granted(first), granted(second), granted(third))
+	}
+	first.Done()
+	if !granted(second) || granted(third) {
+		t.Fatalf("after first.Done: granted = %v %v, want only the second", granted(second), granted(third))
+	}
+	second.Done()
+	if !granted(third) {
+		t.Fatal("after second.Done: third not granted")
+	}
+	third.Done()
+	// Releasing twice must not free a second slot
+	third.Done()
+	if s.running != 0 {
+		t.Errorf("running = %d after all tickets are done, want 0", s.running)
+	}
+}
+
+func TestSchedulerHostLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits map[string]int
+		hosts  []string
+		want   []bool
+	}{
+		{
+			name:   "saturated host does not block others",
+			limits: map[string]int{"github.com": 1},
+			hosts:  []string{"github.com", "github.com", "gitlab.com", "gitlab.com"},
+			want:   []bool{true, false, true, true},
+		},
+		{
+			name:   "any host cap",
+			limits: map[string]int{AnyHost: 1, "github.com": 2},
+			hosts:  []string{"github.com", "github.com", "gitlab.com", "gitlab.com"},
+			want:   []bool{true, true, true, false},
+		},
+		{
+			name:   "global limit",
+			limits: nil,
+			hosts:  []string{"a", "b", "c", "d", "e"},
+			want:   []bool{true, true, true, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(4, tt.limits)
+			for i, host := range tt.hosts {
+				if got := granted(s.Enqueue(host)); got != tt.want[i] {
+					t.Errorf("ticket %d (%s) granted = %v, want %v", i, host, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerCancelledWait(t *testing.T) {
+	s := NewScheduler(1, nil)
+	running := s.Enqueue("a")
+	waiting := s.Enqueue("a")
+	next := s.Enqueue("a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := waiting.Wait(ctx); err == nil {
+		t.Fatal("Wait on a cancelled context succeeded")
+	}
+
+	running.Done()
+	if !granted(next) {
+		t.Error("the ticket behind a cancelled one was not granted")
+	}
+	if granted(waiting) {
+		t.Error("a cancelled ticket was granted")
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	const limit = 3
+	s := NewScheduler(limit, map[string]int{"slow.example": 1})
+
+	var active, peak, slowActive, slowPeak, done atomic.Int32
+	track := func(counter, max *atomic.Int32) func() {
+		n := counter.Add(1)
+		for {
+			old := max.Load()
+			if n <= old || max.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		return func() { counter.Add(-1) }
+	}
+
+	var jobs []Job
+	for i := 0; i < 20; i++ {
+		host := "fast.example"
+		if i%2 == 0 {
+			host = "slow.example"
+		}
+		jobs = append(jobs, Job{Host: host, Run: func(context.Context) {
+			defer track(&active, &peak)()
+			if host == "slow.example" {
+				defer track(&slowActive, &slowPeak)()
+			}
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+		}})
+	}
+	s.Run(context.Background(), jobs)
+
+	if done.Load() != 20 {
+		t.Errorf("%d jobs ran, want 20", done.Load())
+	}
+	if peak.Load() > limit {
+		t.Errorf("%d jobs ran at once, limit is %d", peak.Load(), limit)
+	}
+	if slowPeak.Load() > 1 {
+		t.Errorf("%d jobs ran at once on a host capped at 1", slowPeak.Load())
+	}
+}
+
+func TestSchedulerRunCancelled(t *testing.T) {
+	s := NewScheduler(1, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var ran []int
+	var jobs []Job
+	for i := 0; i < 5; i++ {
+		jobs = append(jobs, Job{Run: func(context.Context) {
+			mu.Lock()
+			ran = append(ran, i)
+			mu.Unlock()
+			// The first job cancels everything still queued
+			cancel()
+		}})
+	}
+	s.Run(ctx, jobs)
+
+	if len(ran) != 1 || ran[0] != 0 {
+		t.Errorf("ran jobs %v, want only the first", ran)
+	}
+}
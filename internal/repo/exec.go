@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ExecCommand builds a command to run in a repository directory. A single
+// argument is handed to the shell, so saved commands such as
+// "git pull && make" work; several arguments are run as they are.
+func ExecCommand(ctx context.Context, repoPath string, argv []string) *exec.Cmd {
+	var cmd *exec.Cmd
+	switch {
+	case len(argv) == 1 && runtime.GOOS == "windows":
+		cmd = exec.CommandContext(ctx, "cmd", "/C", argv[0])
+	case len(argv) == 1:
+		cmd = exec.CommandContext(ctx, "sh", "-c", argv[0])
+	default:
+		cmd = exec.CommandContext(ctx, argv[0], argv[1:]...)
+	}
+	cmd.Dir = repoPath
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = cancelGracePeriod
+	return cmd
+}
+
+// Exec runs a command in a repository directory, writing its combined
+// output to out. Errors from a non-zero exit carry the exit code; see
+// ExitCode.
+func Exec(ctx context.Context, repoPath string, argv []string, out io.Writer) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("no command given")
+	}
+
+	cmd := ExecCommand(ctx, repoPath, argv)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("command cancelled: %w", context.Canceled)
+	}
+	return err
+}
+
+// ExitCode returns the exit code carried by an error from Exec, 0 for nil
+// and -1 if the command did not run to completion
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -2,35 +2,143 @@ package repo
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"get-repo/internal/debug"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// cancelGracePeriod is how long git gets to clean up after an interrupt
+// before it is killed outright.
+const cancelGracePeriod = 5 * time.Second
+
 // GitOperation represents a git operation result
 type GitOperation struct {
 	Success bool
 	Output  string
 	Error   error
+
+	// SubmoduleError is set when the operation itself succeeded but its
+	// submodules could not be initialized or updated
+	SubmoduleError error
+
+	// SkipReason tells why the operation was not attempted, e.g. SkipDirty
+	SkipReason string
+}
+
+// Skipped reports whether the operation was not attempted because it could
+// not be done safely
+func (op GitOperation) Skipped() bool {
+	return op.SkipReason != ""
+}
+
+// CheckoutError explains why Checkout did not move the checkout name to ref,
+// or returns nil if it is on ref now, even when it could not be updated
+func (op GitOperation) CheckoutError(name, ref string) error {
+	switch {
+	case op.SkipReason == SkipDirty:
+		return fmt.Errorf("%s has uncommitted changes and was not switched to %s", name, ref)
+	case op.Skipped():
+		return nil
+	case !op.Success:
+		return fmt.Errorf("checking out %s in %s failed: %w", ref, name, op.Error)
+	case op.SubmoduleError != nil:
+		return fmt.Errorf("checked out %s in %s, but %w", ref, name, op.SubmoduleError)
+	}
+	return nil
+}
+
+// Cancelled reports whether the operation was stopped by its context being
+// cancelled (as opposed to failing or timing out)
+func (op GitOperation) Cancelled() bool {
+	return errors.Is(op.Error, context.Canceled)
+}
+
+// Timeouts limits how long each kind of git operation may run.
+// A zero value means the operation is not limited.
+type Timeouts struct {
+	Clone  time.Duration
+	Pull   time.Duration
+	Status time.Duration
+}
+
+// DefaultTimeouts are used for operations without a configured timeout.
+// Clones are unlimited because large repositories legitimately take long.
+var DefaultTimeouts = Timeouts{
+	Pull:   10 * time.Minute,
+	Status: time.Minute,
 }
 
 // Git handles git operations
 type Git struct {
-	workDir string
+	workDir  string
+	timeouts Timeouts
 }
 
 // NewGit creates a new Git instance
 func NewGit(workDir string) *Git {
-	return &Git{workDir: workDir}
+	return &Git{workDir: workDir, timeouts: DefaultTimeouts}
+}
+
+// SetTimeouts overrides the per-operation timeouts.
+// Zero fields keep their default.
+func (g *Git) SetTimeouts(t Timeouts) {
+	if t.Clone > 0 {
+		g.timeouts.Clone = t.Clone
+	}
+	if t.Pull > 0 {
+		g.timeouts.Pull = t.Pull
+	}
+	if t.Status > 0 {
+		g.timeouts.Status = t.Status
+	}
 }
 
-// Clone clones a repository to the specified destination
-func (g *Git) Clone(url, destination string) GitOperation {
-	// Ensure parent directory exists
+// refKind is what a requested ref turned out to be on the remote
+type refKind int
+
+const (
+	refNone refKind = iota
+	refBranch
+	refTag
+	refCommit
+	refOther // Any other ref, such as refs/pull/1/head
+)
+
+// commitPattern matches abbreviated or full commit hashes
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// Clone clones a repository to the specified destination.
+// If onProgress is non-nil it receives git's transfer progress as it happens.
+// A requested ref is checked against the remote before anything is
+// downloaded; tags and commits are checked out detached. Submodules are
+// initialized recursively afterwards (see SetSubmodulePolicy).
+// When the clone fails or is cancelled, any directories it created are removed.
+func (g *Git) Clone(ctx context.Context, url, destination string, opts CloneOptions, onProgress ProgressFunc) GitOperation {
+	ctx, cancel := withTimeout(ctx, g.timeouts.Clone)
+	defer cancel()
+
+	kind := refNone
+	if opts.Ref != "" {
+		var err error
+		if kind, err = g.resolveRef(ctx, url, opts.Ref); err != nil {
+			return GitOperation{
+				Success: false,
+				Error:   contextError(ctx, "ls-remote", g.timeouts.Clone, err),
+			}
+		}
+	}
+
+	// Ensure parent directory exists, remembering what we create
 	parentDir := filepath.Dir(destination)
+	created := firstMissingDir(destination)
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
 		return GitOperation{
 			Success: false,
@@ -38,25 +146,240 @@ func (g *Git) Clone(url, destination string) GitOperation {
 		}
 	}
 
-	cmd := exec.Command("git", "clone", url, destination)
-	output, err := g.runCommand(cmd)
+	args := []string{"clone", "--progress"}
+	if kind == refBranch || kind == refTag {
+		args = append(args, "--branch", opts.Ref)
+	}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	if opts.Filter != "" {
+		args = append(args, "--filter="+opts.Filter)
+	}
+	if opts.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if len(opts.Sparse) > 0 {
+		args = append(args, "--sparse")
+	}
+	args = append(args, "--", url, destination)
 
-	return GitOperation{
+	cmd := g.command(ctx, args...)
+	output, err := g.runCommandWithProgress(cmd, onProgress)
+	if err == nil && len(opts.Sparse) > 0 {
+		sparse := g.command(ctx, append([]string{"-C", destination, "sparse-checkout", "set", "--cone", "--"}, opts.Sparse...)...)
+		_, err = g.runCommand(sparse)
+	}
+	if err == nil {
+		switch kind {
+		case refCommit:
+			err = g.checkoutCommit(ctx, destination, opts.Ref, opts.Depth)
+		case refOther:
+			err = g.checkoutRef(ctx, destination, opts.Ref, opts.Depth)
+		}
+	}
+	if err != nil {
+		err = contextError(ctx, "clone", g.timeouts.Clone, err)
+		if created != "" {
+			debug.Log("Removing incomplete clone at: %s", created)
+			if rmErr := os.RemoveAll(created); rmErr != nil {
+				debug.LogError(rmErr, fmt.Sprintf("removing incomplete clone %s", created))
+			}
+		}
+	}
+
+	result := GitOperation{
 		Success: err == nil,
 		Output:  output,
 		Error:   err,
 	}
+	if result.Success {
+		result.SubmoduleError = g.updateSubmodules(ctx, destination, opts.Depth, opts.Filter, onProgress)
+	}
+	return result
+}
+
+// resolveRef looks up ref on the remote. Names that match no branch or tag
+// but look like a commit hash are assumed to be commits; those are verified
+// after cloning since remotes do not advertise arbitrary commits.
+func (g *Git) resolveRef(ctx context.Context, url, ref string) (refKind, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+
+	args := []string{"ls-remote", "--heads", "--tags", url, name}
+	if strings.HasPrefix(name, "refs/") {
+		args = []string{"ls-remote", url, name}
+	}
+	output, err := g.runCommand(g.command(ctx, args...))
+	if err != nil {
+		return refNone, err
+	}
+
+	kind := refNone
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[1] {
+		case "refs/heads/" + name:
+			kind = refBranch
+		case "refs/tags/" + name:
+			if kind == refNone {
+				kind = refTag
+			}
+		case name:
+			kind = refOther
+		}
+	}
+
+	switch {
+	case kind != refNone:
+		return kind, nil
+	case commitPattern.MatchString(ref):
+		return refCommit, nil
+	default:
+		return refNone, fmt.Errorf("ref %q not found: no such branch or tag in %s", ref, url)
+	}
+}
+
+// ResolveLinkRef finds where the ref of a browser link ends, for links like
+// ".../tree/feature/login/src" whose branch name may contain slashes (see
+// RemoteURL.HasAmbiguousRef). The remote's branches and tags are listed once
+// and the longest prefix of the link that names one is the ref. Other
+// remotes are returned unchanged.
+func (g *Git) ResolveLinkRef(ctx context.Context, u RemoteURL) (RemoteURL, error) {
+	if !u.HasAmbiguousRef() {
+		return u, nil
+	}
+	ctx, cancel := withTimeout(ctx, g.timeouts.Clone)
+	defer cancel()
+
+	output, err := g.runCommand(g.command(ctx, "ls-remote", "--heads", "--tags", u.CloneURL()))
+	if err != nil {
+		return u, contextError(ctx, "ls-remote", g.timeouts.Clone, err)
+	}
+	return u.splitRefPath(refNames(output, "refs/heads/", "refs/tags/")), nil
 }
 
-// Pull updates a repository
-func (g *Git) Pull(repoPath string) GitOperation {
+// ResolveLinkRefIn is ResolveLinkRef for a remote already checked out at
+// repoPath, answered from its remote-tracking branches and tags without
+// contacting the remote. Where the refs cannot be read, the ref stays cut
+// at the first slash.
+func (g *Git) ResolveLinkRefIn(ctx context.Context, repoPath string, u RemoteURL) RemoteURL {
+	if !u.HasAmbiguousRef() {
+		return u
+	}
+	ctx, cancel := withTimeout(ctx, g.timeouts.Status)
+	defer cancel()
+
+	output, err := g.runCommand(g.command(ctx, "-C", repoPath, "for-each-ref", "--format=%(objectname) %(refname)",
+		"refs/heads", "refs/remotes/origin", "refs/tags"))
+	if err != nil {
+		debug.LogError(err, "listing refs of "+repoPath)
+	}
+	return u.splitRefPath(refNames(output, "refs/heads/", "refs/remotes/origin/", "refs/tags/"))
+}
+
+// refNames collects the ref names from "<object> <ref>" lines, such as the
+// output of ls-remote, with the first matching prefix removed
+func refNames(output string, prefixes ...string) map[string]bool {
+	names := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		ref := strings.TrimSuffix(fields[1], "^{}")
+		for _, prefix := range prefixes {
+			if name, ok := strings.CutPrefix(ref, prefix); ok {
+				names[name] = true
+				break
+			}
+		}
+	}
+	return names
+}
+
+// checkoutRef fetches a ref that clone does not download, such as a pull
+// request head, and detaches a fresh clone at it
+func (g *Git) checkoutRef(ctx context.Context, repoPath, ref string, depth int) error {
+	args := []string{"-C", repoPath, "fetch", "--quiet"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	fetch := g.command(ctx, append(args, "origin", ref)...)
+	if _, err := g.runCommand(fetch); err != nil {
+		return err
+	}
+
+	cmd := g.command(ctx, "-C", repoPath, "checkout", "--quiet", "--detach", "FETCH_HEAD")
+	_, err := g.runCommand(cmd)
+	return err
+}
+
+// checkoutCommit detaches a fresh clone at the given commit. Shallow clones
+// may not contain it, so it is fetched directly from the remote then.
+func (g *Git) checkoutCommit(ctx context.Context, repoPath, commit string, depth int) error {
+	verify := g.command(ctx, "-C", repoPath, "rev-parse", "--quiet", "--verify", commit+"^{commit}")
+	if _, err := g.runCommand(verify); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if depth > 0 && g.checkoutRef(ctx, repoPath, commit, depth) == nil {
+			return nil
+		}
+		return fmt.Errorf("ref %q not found: no such branch, tag or commit", commit)
+	}
+
+	cmd := g.command(ctx, "-C", repoPath, "checkout", "--quiet", "--detach", commit)
+	_, err := g.runCommand(cmd)
+	return err
+}
+
+// Pull updates a repository using the given strategy and then its
+// submodules. Repositories that cannot be updated safely (uncommitted changes,
+// detached HEAD, no upstream branch) are skipped, see GitOperation.Skipped.
+// A rebase that stops on conflicts is aborted. Bare repositories are fetched
+// instead, and submodules are skipped: their superproject decides which
+// commit they are at.
+// If onProgress is non-nil it receives git's transfer progress as it happens.
+func (g *Git) Pull(ctx context.Context, repoPath string, strategy UpdateStrategy, onProgress ProgressFunc) GitOperation {
 	defer debug.LogFunction("Git.Pull")()
-	debug.Log("Pulling repository at: %s", repoPath)
+	debug.Log("Pulling repository at: %s (%s)", repoPath, strategy)
+
+	ctx, cancel := withTimeout(ctx, g.timeouts.Pull)
+	defer cancel()
 
-	cmd := exec.Command("git", "-C", repoPath, "pull")
+	switch kind, _ := DetectKind(repoPath); kind {
+	case KindBare:
+		return g.fetchBare(ctx, repoPath, onProgress)
+	case KindSubmodule:
+		debug.Log("Skipping %s: %s", repoPath, SkipSubmodule)
+		return GitOperation{Success: false, SkipReason: SkipSubmodule}
+	}
+
+	if reason := g.skipReason(ctx, repoPath, strategy); reason != "" {
+		if ctx.Err() != nil {
+			return GitOperation{Success: false, Error: contextError(ctx, "pull", g.timeouts.Pull, ctx.Err())}
+		}
+		debug.Log("Skipping %s: %s", repoPath, reason)
+		return GitOperation{Success: false, SkipReason: reason}
+	}
+
+	args := append([]string{"-C", repoPath, "pull", "--progress"}, strategy.pullArgs()...)
+	cmd := g.command(ctx, args...)
 	debug.Log("Executing command: %s", cmd.String())
 
-	output, err := g.runCommand(cmd)
+	output, err := g.runCommandWithProgress(cmd, onProgress)
+	if err != nil {
+		if strategy != StrategyFFOnly {
+			g.abortRebase(repoPath)
+		}
+		err = contextError(ctx, "pull", g.timeouts.Pull, err)
+	} else if strategy == StrategyAutostash && g.hasConflicts(ctx, repoPath) {
+		// git keeps the stash when it cannot be re-applied cleanly
+		err = fmt.Errorf("re-applying local changes after the update conflicted; they are kept in the stash (git stash list)")
+	}
 
 	result := GitOperation{
 		Success: err == nil,
@@ -68,16 +391,101 @@ func (g *Git) Pull(repoPath string) GitOperation {
 		debug.LogError(err, fmt.Sprintf("git pull failed for %s", repoPath))
 	} else {
 		debug.Log("Git pull successful for %s", repoPath)
+		result.SubmoduleError = g.updateSubmodules(ctx, repoPath, 0, "", onProgress)
 	}
 	debug.Log("Git pull output: %s", output)
 
 	return result
 }
 
+// fetchBare updates a bare repository, which has no working tree to pull
+// into. Mirrors fetch with their configured refspec; other bare clones have
+// none, so their branches are replaced by the remote's.
+func (g *Git) fetchBare(ctx context.Context, repoPath string, onProgress ProgressFunc) GitOperation {
+	args := []string{"-C", repoPath, "fetch", "--progress", "--prune", "--tags", "origin"}
+	if _, err := g.runCommand(g.command(ctx, "-C", repoPath, "config", "--get-all", "remote.origin.fetch")); err != nil {
+		args = append(args, "+refs/heads/*:refs/heads/*")
+	}
+
+	output, err := g.runCommandWithProgress(g.command(ctx, args...), onProgress)
+	if err != nil {
+		return GitOperation{Success: false, Error: contextError(ctx, "fetch", g.timeouts.Pull, err)}
+	}
+	return GitOperation{Success: true, Output: output}
+}
+
+// FastForward updates a repository from its upstream only if no merge is
+// needed, and then its submodules. It is Pull with StrategyFFOnly.
+func (g *Git) FastForward(ctx context.Context, repoPath string, onProgress ProgressFunc) GitOperation {
+	return g.Pull(ctx, repoPath, StrategyFFOnly, onProgress)
+}
+
+// Unshallow converts a shallow, partial or single-branch clone into a full
+// clone by fetching the missing history, objects and branches.
+// If onProgress is non-nil it receives git's transfer progress.
+func (g *Git) Unshallow(ctx context.Context, repoPath string, onProgress ProgressFunc) GitOperation {
+	ctx, cancel := withTimeout(ctx, g.timeouts.Clone)
+	defer cancel()
+
+	var steps []string
+	args := []string{"-C", repoPath, "fetch", "--progress"}
+
+	shallow, _ := g.runCommand(g.command(ctx, "-C", repoPath, "rev-parse", "--is-shallow-repository"))
+	if strings.TrimSpace(shallow) == "true" {
+		args = append(args, "--unshallow")
+		steps = append(steps, "history")
+	}
+
+	// A partial clone stops being one once its filter is gone and everything
+	// is fetched again
+	filter, _ := g.runCommand(g.command(ctx, "-C", repoPath, "config", "--get", "remote.origin.partialclonefilter"))
+	if strings.TrimSpace(filter) != "" {
+		unset := g.command(ctx, "-C", repoPath, "config", "--unset", "remote.origin.partialclonefilter")
+		if _, err := g.runCommand(unset); err != nil {
+			return GitOperation{Success: false, Error: err}
+		}
+		args = append(args, "--refetch")
+		steps = append(steps, "objects")
+	}
+
+	// Single-branch clones only track one branch
+	refspecs, _ := g.runCommand(g.command(ctx, "-C", repoPath, "config", "--get-all", "remote.origin.fetch"))
+	if !strings.Contains(refspecs, "refs/heads/*") {
+		widen := g.command(ctx, "-C", repoPath, "remote", "set-branches", "origin", "*")
+		if _, err := g.runCommand(widen); err != nil {
+			return GitOperation{Success: false, Error: err}
+		}
+		steps = append(steps, "branches")
+	}
+
+	if len(steps) == 0 {
+		return GitOperation{Success: true, Output: "already a full clone"}
+	}
+
+	output, err := g.runCommandWithProgress(g.command(ctx, append(args, "origin")...), onProgress)
+	if err != nil {
+		err = contextError(ctx, "fetch", g.timeouts.Clone, err)
+	} else {
+		output = "fetched missing " + strings.Join(steps, ", ")
+	}
+
+	return GitOperation{
+		Success: err == nil,
+		Output:  output,
+		Error:   err,
+	}
+}
+
 // Status gets the status of a repository
-func (g *Git) Status(repoPath string) GitOperation {
-	cmd := exec.Command("git", "-C", repoPath, "status", "--porcelain")
+func (g *Git) Status(ctx context.Context, repoPath string) GitOperation {
+	ctx, cancel := withTimeout(ctx, g.timeouts.Status)
+	defer cancel()
+
+	cmd := g.command(ctx, "-C", repoPath, "status", "--porcelain")
 	output, err := g.runCommand(cmd)
+	if err != nil {
+		err = contextError(ctx, "status", g.timeouts.Status, err)
+	}
 
 	return GitOperation{
 		Success: err == nil,
@@ -87,26 +495,98 @@ func (g *Git) Status(repoPath string) GitOperation {
 }
 
 // HasUncommittedChanges checks if a repository has uncommitted changes
-func (g *Git) HasUncommittedChanges(repoPath string) bool {
-	result := g.Status(repoPath)
+func (g *Git) HasUncommittedChanges(ctx context.Context, repoPath string) bool {
+	result := g.Status(ctx, repoPath)
 	return result.Success && strings.TrimSpace(result.Output) != ""
 }
 
 // GetRemoteURL gets the remote URL of a repository
-func (g *Git) GetRemoteURL(repoPath string) (string, error) {
-	cmd := exec.Command("git", "-C", repoPath, "config", "--get", "remote.origin.url")
+func (g *Git) GetRemoteURL(ctx context.Context, repoPath string) (string, error) {
+	ctx, cancel := withTimeout(ctx, g.timeouts.Status)
+	defer cancel()
+
+	cmd := g.command(ctx, "-C", repoPath, "config", "--get", "remote.origin.url")
 	output, err := g.runCommand(cmd)
 	if err != nil {
-		return "", err
+		return "", contextError(ctx, "config", g.timeouts.Status, err)
 	}
 	return strings.TrimSpace(output), nil
 }
 
-// IsGitRepository checks if a path is a git repository
+// ExistingCheckout reports whether the checkout name at repoPath already
+// exists as a clone of remote, so cloning it again can reuse it. Anything
+// else in its place (another remote, a plain directory) is an error.
+func (g *Git) ExistingCheckout(ctx context.Context, remote RemoteURL, name, repoPath string) (bool, error) {
+	if _, err := os.Stat(repoPath); err != nil {
+		return false, nil
+	}
+
+	if !IsGitRepository(repoPath) {
+		return true, fmt.Errorf("%s already exists and is not a git repository", name)
+	}
+	actual, err := g.GetRemoteURL(ctx, repoPath)
+	if err != nil {
+		return true, fmt.Errorf("%s already exists but has no origin remote", name)
+	}
+	if !remote.Matches(actual) {
+		return true, fmt.Errorf("%s already exists with a different remote: %s", name, actual)
+	}
+	return true, nil
+}
+
+// IsGitRepository checks if a path is a git repository of any kind: a
+// checkout, a linked worktree, a submodule or a bare repository
 func IsGitRepository(path string) bool {
-	gitPath := filepath.Join(path, ".git")
-	info, err := os.Stat(gitPath)
-	return err == nil && info.IsDir()
+	_, ok := DetectKind(path)
+	return ok
+}
+
+// command builds a git command bound to ctx. On cancellation git is first
+// interrupted so it can clean up, and killed if it does not exit in time.
+func (g *Git) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = cancelGracePeriod
+	return cmd
+}
+
+// withTimeout derives a context limited by timeout, or a plain cancellable
+// child of ctx when timeout is zero
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextError replaces a command error with a clearer one when the command
+// was stopped by cancellation or by its timeout
+func contextError(ctx context.Context, operation string, timeout time.Duration, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("git %s timed out after %s: %w", operation, timeout, context.DeadlineExceeded)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("git %s cancelled: %w", operation, context.Canceled)
+	default:
+		return err
+	}
+}
+
+// firstMissingDir returns the outermost ancestor of path (or path itself)
+// that does not exist yet, or "" if path already exists
+func firstMissingDir(path string) string {
+	missing := ""
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			return missing
+		}
+		missing = dir
+		if parent := filepath.Dir(dir); parent == dir {
+			return missing
+		}
+	}
 }
 
 // runCommand executes a command and returns combined output
@@ -126,3 +606,23 @@ func (g *Git) runCommand(cmd *exec.Cmd) (string, error) {
 
 	return output, nil
 }
+
+// runCommandWithProgress executes a command, streaming stderr through a
+// progress parser. Non-progress stderr lines are kept for error reporting.
+func (g *Git) runCommandWithProgress(cmd *exec.Cmd, onProgress ProgressFunc) (string, error) {
+	var stdout bytes.Buffer
+	stderr := newProgressWriter(onProgress)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	output := stdout.String()
+	if err != nil {
+		if errOutput := stderr.String(); errOutput != "" {
+			output = errOutput
+		}
+		return output, fmt.Errorf("%s: %w", strings.TrimSpace(output), err)
+	}
+
+	return output, nil
+}
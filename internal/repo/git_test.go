@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestExistingCheckout(t *testing.T) {
+	checkout := testCheckout(t)
+	origin := filepath.Join(filepath.Dir(checkout), "origin")
+	plain := t.TempDir()
+	g := NewGit(filepath.Dir(checkout))
+	ctx := context.Background()
+
+	same, err := ParseRemoteURL(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ParseRemoteURL("https://github.com/me/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  RemoteURL
+		path    string
+		exists  bool
+		wantErr bool
+	}{
+		{name: "same remote", remote: same, path: checkout, exists: true},
+		{name: "different remote", remote: other, path: checkout, exists: true, wantErr: true},
+		{name: "not a repository", remote: same, path: plain, exists: true, wantErr: true},
+		{name: "missing", remote: same, path: filepath.Join(plain, "missing")},
+	}
+	for _, tt := range tests {
+		exists, err := g.ExistingCheckout(ctx, tt.remote, "checkout", tt.path)
+		if exists != tt.exists || (err != nil) != tt.wantErr {
+			t.Errorf("%s: ExistingCheckout = %v, %v; want %v, error %v", tt.name, exists, err, tt.exists, tt.wantErr)
+		}
+	}
+
+	// A checkout without origin cannot be matched to the remote
+	if err := exec.Command("git", "-C", checkout, "remote", "remove", "origin").Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.ExistingCheckout(ctx, same, "checkout", checkout); err == nil {
+		t.Error("ExistingCheckout accepted a checkout without origin")
+	}
+}
@@ -0,0 +1,168 @@
+package repo
+
+import (
+	"get-repo/internal/debug"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// IgnoreFileName is the name of the files, in gitignore syntax, that leave
+// directories out of scans. One applies to the directory it is in and
+// everything below it.
+const IgnoreFileName = ".get-repoignore"
+
+// ignoreRule is one pattern of an ignore file
+type ignoreRule struct {
+	re     *regexp.Regexp
+	negate bool // "!pattern": a match is not ignored after all
+}
+
+// ignoreFile holds the rules of one ignore file
+type ignoreFile struct {
+	dir   string // Slash-separated directory of the file below the base path, "" for the base path
+	rules []ignoreRule
+}
+
+// ignoreRules are the ignore files that apply to a directory, outermost
+// first
+type ignoreRules []ignoreFile
+
+// with returns the rules extended by f, leaving r unchanged so sibling
+// directories can extend it too
+func (r ignoreRules) with(f ignoreFile) ignoreRules {
+	return append(slices.Clip(r), f)
+}
+
+// ignored reports whether the directory at rel, a slash-separated path below
+// the base path, is ignored. As in git, the last matching pattern decides
+// and patterns of deeper files come after those of their parents.
+func (r ignoreRules) ignored(rel string) bool {
+	ignored := false
+	for _, f := range r {
+		sub := rel
+		if f.dir != "" {
+			var found bool
+			if sub, found = strings.CutPrefix(rel, f.dir+"/"); !found {
+				continue
+			}
+		}
+		for _, rule := range f.rules {
+			if rule.re.MatchString(sub) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// loadIgnoreFile reads the ignore file in dirPath, whose path below the base
+// path is rel, and reports whether there is one
+func loadIgnoreFile(dirPath, rel string) (ignoreFile, bool) {
+	data, err := os.ReadFile(filepath.Join(dirPath, IgnoreFileName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.LogError(err, "reading "+filepath.Join(dirPath, IgnoreFileName))
+		}
+		return ignoreFile{}, false
+	}
+	return parseIgnoreFile(filepath.ToSlash(rel), string(data)), true
+}
+
+// parseIgnoreFile parses the gitignore syntax of an ignore file in dir.
+// Patterns that do not compile are skipped, as git does.
+func parseIgnoreFile(dir, data string) ignoreFile {
+	f := ignoreFile{dir: dir}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		negate := false
+		if strings.HasPrefix(line, "!") {
+			negate = true
+			line = line[1:]
+		}
+		re, err := compileIgnorePattern(line)
+		if err != nil {
+			debug.Log("Skipping ignore pattern %q in %s: %v", line, path.Join(dir, IgnoreFileName), err)
+			continue
+		}
+		f.rules = append(f.rules, ignoreRule{re: re, negate: negate})
+	}
+	return f
+}
+
+// compileIgnorePattern turns a gitignore pattern into a regular expression
+// over slash-separated paths below the ignore file. A pattern with a slash
+// other than a trailing one is anchored to the file's directory; others
+// match a directory name at any depth. "**" spans directories.
+func compileIgnorePattern(pattern string) (*regexp.Regexp, error) {
+	// Only directories are ever matched, so a trailing slash changes nothing
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case pattern[i:] == "**":
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// excluded reports whether the scan would leave out the repository name,
+// because a directory on its path is hidden, matches the scan policy or is
+// ignored by an ignore file
+func (m *Manager) excluded(name string) bool {
+	policy := currentScanPolicy()
+	var rules ignoreRules
+	rel := ""
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if f, ok := loadIgnoreFile(filepath.Join(m.basePath, filepath.FromSlash(rel)), rel); ok {
+			rules = rules.with(f)
+		}
+		rel = path.Join(rel, part)
+		if policy.ignored(rel) || rules.ignored(rel) {
+			return true
+		}
+	}
+	return false
+}
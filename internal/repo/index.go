@@ -0,0 +1,384 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"get-repo/internal/debug"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexFileName is the name of the repository index in the cache directory
+const IndexFileName = "index.json"
+
+// IndexEntry is what the index remembers about a repository
+type IndexEntry struct {
+	Kind      Kind      `json:"kind"`
+	URL       string    `json:"url,omitempty"`    // Remote URL of origin
+	Branch    string    `json:"branch,omitempty"` // Checked out branch, "" when detached
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+}
+
+// indexedDir is a directory the scan searched, or an ignore file it read.
+// Its modification time changes when entries are added to or removed from
+// the directory or the file is edited, which invalidates the index.
+type indexedDir struct {
+	ModTime time.Time `json:"mod_time"`
+	Listed  bool      `json:"listed,omitempty"` // Reported as an organizational directory
+}
+
+// indexData is the on-disk form of an Index
+type indexData struct {
+	BasePath     string                `json:"base_path"`
+	Policy       string                `json:"scan_policy"`
+	Repositories map[string]IndexEntry `json:"repositories"`
+	Directories  map[string]indexedDir `json:"directories"`
+}
+
+// Index caches the result of scanning a base path in a JSON file, so
+// repositories can be listed without walking the disk. A full scan rebuilds
+// it (see Manager.OpenIndex); clones, updates and removals keep it current
+// with Record and Remove. It is safe for concurrent use.
+type Index struct {
+	path     string
+	mu       sync.Mutex
+	data     indexData
+	dirty    bool
+	excluded func(name string) bool // Whether a scan would leave a repository out, see Manager.OpenIndex
+}
+
+// LoadIndex reads the index of basePath from path. A missing or unreadable
+// file, or one written for another base path, gives an empty index, since
+// the next scan rebuilds it.
+func LoadIndex(path, basePath string) *Index {
+	ix := &Index{path: path, data: indexData{BasePath: basePath}}
+	if path == "" {
+		return ix
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.LogError(err, "reading repository index")
+		}
+		return ix
+	}
+	var stored indexData
+	if err := json.Unmarshal(data, &stored); err != nil {
+		debug.LogError(err, "parsing repository index")
+		return ix
+	}
+	if stored.BasePath != basePath {
+		debug.Log("Ignoring repository index of %s", stored.BasePath)
+		return ix
+	}
+	ix.data = stored
+	return ix
+}
+
+// Get returns what the index knows about a repository
+func (ix *Index) Get(name string) (IndexEntry, bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	e, ok := ix.data.Repositories[filepath.ToSlash(name)]
+	return e, ok
+}
+
+// Record refreshes the entry of a repository after it was cloned, updated or
+// moved, along with the directories above it. Until a full scan has built
+// the index there is nothing to keep current and Record does nothing.
+func (ix *Index) Record(name string) {
+	ix.record(name, false)
+}
+
+// RecordFetch is Record for a repository that was just fetched from its
+// remote
+func (ix *Index) RecordFetch(name string) {
+	ix.record(name, true)
+}
+
+func (ix *Index) record(name string, fetched bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.data.Directories == nil {
+		return
+	}
+
+	key := filepath.ToSlash(name)
+	entry, ok := readIndexEntry(filepath.Join(ix.data.BasePath, name))
+	if !ok || (ix.excluded != nil && ix.excluded(name)) {
+		delete(ix.data.Repositories, key)
+	} else {
+		previous := ix.data.Repositories[key]
+		entry.FetchedAt = previous.FetchedAt
+		if fetched {
+			entry.FetchedAt = time.Now()
+		}
+		ix.data.Repositories[key] = entry
+	}
+	ix.restatParents(key)
+	ix.dirty = true
+}
+
+// Remove forgets a repository and everything below it after it was deleted
+// or moved away
+func (ix *Index) Remove(name string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.data.Directories == nil {
+		return
+	}
+
+	key := filepath.ToSlash(name)
+	for other := range ix.data.Repositories {
+		if other == key || strings.HasPrefix(other, key+"/") {
+			delete(ix.data.Repositories, other)
+		}
+	}
+	for dir := range ix.data.Directories {
+		if dir == key || strings.HasPrefix(dir, key+"/") {
+			delete(ix.data.Directories, dir)
+		}
+	}
+	ix.restatParents(key)
+	ix.dirty = true
+}
+
+// restatParents records the current modification times of the directories
+// above key, which a clone or removal has just changed, and drops those that
+// no longer exist
+func (ix *Index) restatParents(key string) {
+	for dir := parentKey(key); ; dir = parentKey(dir) {
+		info, err := os.Stat(filepath.Join(ix.data.BasePath, filepath.FromSlash(dir)))
+		if err != nil {
+			delete(ix.data.Directories, dir)
+		} else {
+			depth := strings.Count(dir, "/") + 1
+			ix.data.Directories[dir] = indexedDir{
+				ModTime: info.ModTime(),
+				Listed:  dir != "" && depth <= organizationDepth && !ix.insideRepository(dir),
+			}
+		}
+		if dir == "" {
+			return
+		}
+	}
+}
+
+// insideRepository reports whether dir is below an indexed repository
+func (ix *Index) insideRepository(dir string) bool {
+	for parent := parentKey(dir); parent != ""; parent = parentKey(parent) {
+		if _, ok := ix.data.Repositories[parent]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parentKey returns the slash-separated parent of key, "" for the base path
+func parentKey(key string) string {
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
+
+// repositories returns the indexed repositories and organizational
+// directories the way Manager.List orders them. It fails if the index was
+// never built, was built with another scan policy or, when verify is set, if
+// a searched directory changed since.
+func (ix *Index) repositories(policy string, verify bool) ([]Repository, bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.data.Directories == nil || ix.data.Policy != policy {
+		return nil, false
+	}
+	if verify {
+		for dir, state := range ix.data.Directories {
+			info, err := os.Stat(filepath.Join(ix.data.BasePath, filepath.FromSlash(dir)))
+			if err != nil || !info.ModTime().Equal(state.ModTime) {
+				debug.Log("Repository index is stale: %q changed", dir)
+				return nil, false
+			}
+		}
+	}
+
+	var repos, dirs []Repository
+	for name, entry := range ix.data.Repositories {
+		repos = append(repos, Repository{
+			Name:     filepath.FromSlash(name),
+			Path:     filepath.Join(ix.data.BasePath, filepath.FromSlash(name)),
+			IsGitDir: true,
+			Kind:     entry.Kind,
+		})
+	}
+	for name, state := range ix.data.Directories {
+		if state.Listed {
+			dirs = append(dirs, Repository{
+				Name: filepath.FromSlash(name),
+				Path: filepath.Join(ix.data.BasePath, filepath.FromSlash(name)),
+			})
+		}
+	}
+	SortRepositories(repos)
+	SortRepositories(dirs)
+	return append(repos, dirs...), true
+}
+
+// rebuild replaces the index with the result of a full scan: the
+// repositories found and the directories searched. Repository details are
+// read from their git directories in parallel; fetch times are kept.
+func (ix *Index) rebuild(policy string, repos []Repository, dirs map[string]indexedDir) {
+	entries := make([]IndexEntry, len(repos))
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, runtime.NumCPU())
+	for i, r := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			entries[i], _ = readIndexEntry(r.Path)
+			entries[i].Kind = r.Kind
+		}()
+	}
+	wg.Wait()
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	previous := ix.data.Repositories
+	ix.data.Policy = policy
+	ix.data.Repositories = make(map[string]IndexEntry, len(repos))
+	for i, r := range repos {
+		key := filepath.ToSlash(r.Name)
+		entries[i].FetchedAt = previous[key].FetchedAt
+		ix.data.Repositories[key] = entries[i]
+	}
+	ix.data.Directories = dirs
+	ix.dirty = true
+}
+
+// Save writes the index back to its file if it changed
+func (ix *Index) Save() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.path == "" || !ix.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(ix.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode repository index: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first, so concurrent readers never see a
+	// partial index
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write repository index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		return fmt.Errorf("failed to write repository index: %w", err)
+	}
+	ix.dirty = false
+	return nil
+}
+
+// readIndexEntry reads the kind, origin URL and branch of the repository at
+// dir straight from its git directory, without running git
+func readIndexEntry(dir string) (IndexEntry, bool) {
+	kind, ok := DetectKind(dir)
+	if !ok {
+		return IndexEntry{}, false
+	}
+
+	gitDir := filepath.Join(dir, ".git")
+	switch kind {
+	case KindBare:
+		gitDir = dir
+	case KindWorktree, KindSubmodule:
+		gitDir = gitlinkTarget(dir)
+	default:
+		if info, err := os.Stat(gitDir); err == nil && !info.IsDir() {
+			gitDir = gitlinkTarget(dir)
+		}
+	}
+
+	// Linked worktrees share the configuration of their main repository
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	entry := IndexEntry{Kind: kind, URL: originURL(commonDir)}
+	if head, err := os.ReadFile(filepath.Join(gitDir, "HEAD")); err == nil {
+		if ref, found := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/"); found {
+			entry.Branch = ref
+		}
+	}
+	return entry, true
+}
+
+// gitlinkTarget returns the git directory the ".git" file of dir points at
+func gitlinkTarget(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return filepath.Join(dir, ".git")
+	}
+	target := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir: "))
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return target
+}
+
+// originURL returns the URL of the "origin" remote from the git config file
+// in gitDir, or "" if there is none
+func originURL(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return ""
+	}
+
+	inOrigin := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section := strings.Join(strings.Fields(strings.Trim(line, "[]")), " ")
+			inOrigin = section == `remote "origin"`
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if inOrigin && found && strings.EqualFold(strings.TrimSpace(key), "url") {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// RemoteHost returns the host of the origin remote of the repository at
+// repoPath, such as "github.com", for keying per-host limits like
+// RemoteURL.Host does for clones. Remotes on the filesystem and repositories
+// without a usable origin give "". The git config is read directly, without
+// running git.
+func RemoteHost(repoPath string) string {
+	entry, ok := readIndexEntry(repoPath)
+	if !ok || entry.URL == "" {
+		return ""
+	}
+	remote, err := ParseRemoteURL(entry.URL)
+	if err != nil {
+		return ""
+	}
+	return remote.Host
+}
@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kind tells how a repository is laid out on disk
+type Kind int
+
+const (
+	KindNormal    Kind = iota // Working tree with its own git directory
+	KindWorktree              // Linked worktree of another repository ("git worktree add")
+	KindBare                  // No working tree, e.g. "git clone --mirror"
+	KindSubmodule             // Submodule checkout inside its superproject
+)
+
+// String returns the name shown for the kind, e.g. "worktree"
+func (k Kind) String() string {
+	switch k {
+	case KindWorktree:
+		return "worktree"
+	case KindBare:
+		return "bare"
+	case KindSubmodule:
+		return "submodule"
+	default:
+		return "normal"
+	}
+}
+
+// MarshalText encodes the kind by name, e.g. in JSON output
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind encoded by MarshalText
+func (k *Kind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "normal":
+		*k = KindNormal
+	case "worktree":
+		*k = KindWorktree
+	case "bare":
+		*k = KindBare
+	case "submodule":
+		*k = KindSubmodule
+	default:
+		return fmt.Errorf("unknown repository kind %q", text)
+	}
+	return nil
+}
+
+// DetectKind reports whether dir is a git repository and of which kind
+func DetectKind(dir string) (Kind, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return KindNormal, false
+	}
+	return kindOf(dir, entries)
+}
+
+// kindOf detects the kind of the repository at dir from its directory
+// entries: a ".git" directory, a gitlink file or the layout of a bare
+// repository (HEAD, objects and refs)
+func kindOf(dir string, entries []os.DirEntry) (Kind, bool) {
+	var head, objects, refs bool
+	for _, entry := range entries {
+		switch entry.Name() {
+		case ".git":
+			// Stat rather than the entry, so a symlinked .git counts too
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info.IsDir() {
+				return KindNormal, true
+			}
+			return gitlinkKind(filepath.Join(dir, ".git"))
+		case "HEAD":
+			head = entry.Type().IsRegular()
+		case "objects":
+			objects = entry.IsDir()
+		case "refs":
+			refs = entry.IsDir()
+		}
+	}
+	if head && objects && refs {
+		return KindBare, true
+	}
+	return KindNormal, false
+}
+
+// gitlinkKind classifies a checkout by where its ".git" file points: into
+// the worktrees of another repository, into the modules of a superproject,
+// or to a separate git directory ("git clone --separate-git-dir")
+func gitlinkKind(gitPath string) (Kind, bool) {
+	if !isGitlink(gitPath) {
+		return KindNormal, false
+	}
+	data, _ := os.ReadFile(gitPath)
+	gitDir := filepath.ToSlash(strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir: ")))
+	switch {
+	case strings.Contains(gitDir, "/worktrees/"):
+		return KindWorktree, true
+	case strings.Contains(gitDir, "/modules/"):
+		return KindSubmodule, true
+	default:
+		return KindNormal, true
+	}
+}
+
+// submodulePaths returns the paths of the submodules a checkout declares in
+// .gitmodules, whether or not they are initialized
+func submodulePaths(repoPath string) []string {
+	data, err := os.ReadFile(filepath.Join(repoPath, ".gitmodules"))
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if found && strings.TrimSpace(key) == "path" {
+			if p := strings.Trim(strings.TrimSpace(value), `"`); p != "" {
+				paths = append(paths, filepath.FromSlash(p))
+			}
+		}
+	}
+	return paths
+}
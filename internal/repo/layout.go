@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultLayout places repositories under their host and full path,
+// e.g. github.com/user/repo or gitlab.com/group/subgroup/repo
+const DefaultLayout = "{{.Host}}/{{.Owner}}/{{.Repo}}"
+
+// Layout controls where repositories are checked out below the base path
+type Layout struct {
+	Template    string            // text/template over LayoutFields; DefaultLayout if empty
+	Lowercase   bool              // Lowercase the resulting path
+	HostAliases map[string]string // Directory names for hosts, e.g. "github.com": "gh"
+}
+
+// LayoutFields are the values available to a layout template
+type LayoutFields struct {
+	Host  string // Host name after aliasing, "local" for local repositories
+	Owner string // Everything between host and repository, e.g. "group/subgroup"
+	Repo  string // Repository name
+	Path  string // Owner and repository, e.g. "group/subgroup/repo"
+}
+
+// layoutFuncs are the helpers available in layout templates
+var layoutFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"replace": strings.ReplaceAll,
+}
+
+var (
+	layoutMu       sync.RWMutex
+	layout         Layout
+	layoutTemplate = template.Must(template.New("layout").Funcs(layoutFuncs).Parse(DefaultLayout))
+)
+
+// SetLayout installs the clone path layout used by RemoteURL.ClonePath
+func SetLayout(l Layout) error {
+	l, tmpl, err := compileLayout(l)
+	if err != nil {
+		return err
+	}
+
+	layoutMu.Lock()
+	layout = l
+	layoutTemplate = tmpl
+	layoutMu.Unlock()
+	return nil
+}
+
+// compileLayout validates a layout and parses its template
+func compileLayout(l Layout) (Layout, *template.Template, error) {
+	if l.Template == "" {
+		l.Template = DefaultLayout
+	}
+
+	tmpl, err := template.New("layout").Funcs(layoutFuncs).Option("missingkey=error").Parse(l.Template)
+	if err != nil {
+		return l, nil, fmt.Errorf("invalid layout template: %w", err)
+	}
+
+	sample := LayoutFields{Host: "example.com", Owner: "owner", Repo: "repo", Path: "owner/repo"}
+	if _, err := renderLayout(tmpl, sample); err != nil {
+		return l, nil, fmt.Errorf("invalid layout template %q: %w", l.Template, err)
+	}
+
+	aliases := make(map[string]string, len(l.HostAliases))
+	for host, alias := range l.HostAliases {
+		aliases[strings.ToLower(host)] = alias
+	}
+	l.HostAliases = aliases
+	return l, tmpl, nil
+}
+
+// ClonePath returns the relative path where the repository is checked out,
+// following the configured layout (see SetLayout). Scheme, user, port and
+// ".git" suffix do not affect it, so every spelling of the same remote lands
+// in the same place. With roots installed (see SetRoots) the path starts
+// with the root the remote is cloned into and follows its layout.
+func (u RemoteURL) ClonePath() string {
+	if r, ok := rootFor(u); ok {
+		return r.clonePath(u)
+	}
+
+	layoutMu.RLock()
+	defer layoutMu.RUnlock()
+	return layoutPath(u, layout, layoutTemplate)
+}
+
+// layoutPath renders the checkout path of the remote with a layout
+func layoutPath(u RemoteURL, layout Layout, layoutTemplate *template.Template) string {
+	host := u.Host
+	if u.Local {
+		host = localHost
+	}
+	if alias, ok := layout.HostAliases[host]; ok {
+		host = alias
+	}
+
+	fields := LayoutFields{
+		Host:  host,
+		Owner: u.Owner(),
+		Repo:  u.Name(),
+		Path:  u.Path(),
+	}
+	p, err := renderLayout(layoutTemplate, fields)
+	if err != nil {
+		// SetLayout rejects templates that fail to render, so this only
+		// happens for unusual names; fall back to the canonical path
+		p = u.Key()
+	}
+	if layout.Lowercase {
+		p = strings.ToLower(p)
+	}
+	return p
+}
+
+// Key returns the canonical identity of the repository, host followed by the
+// path segments. Unlike ClonePath it does not depend on the layout, so it is
+// used for duplicate detection.
+func (u RemoteURL) Key() string {
+	host := u.Host
+	if u.Local {
+		host = localHost
+	}
+	return path.Join(append([]string{host}, u.Segments...)...)
+}
+
+// renderLayout executes a layout template and cleans the result into a
+// relative slash-separated path
+func renderLayout(tmpl *template.Template, fields LayoutFields) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, fields); err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(strings.ReplaceAll(b.String(), "\\", "/"), "/") {
+		segment = strings.TrimSpace(segment)
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("layout must not produce '..'")
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("layout produced an empty path")
+	}
+	return strings.Join(segments, "/"), nil
+}
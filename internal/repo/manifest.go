@@ -0,0 +1,214 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes a workspace: the repositories to check out and how.
+// It is read from YAML, or from JSON when the file name ends in ".json".
+type Manifest struct {
+	Repos []ManifestRepo `json:"repos" yaml:"repos"`
+}
+
+// ManifestRepo is a repository in a manifest. Depth, filter, single branch
+// and sparse are clone options as on the command line; like those, they only
+// apply when the repository is cloned.
+type ManifestRepo struct {
+	URL          string        `json:"url" yaml:"url"`                     // URL or shorthand, may carry "@ref"
+	Ref          string        `json:"ref,omitempty" yaml:"ref,omitempty"` // Branch, tag or commit to keep checked out
+	Depth        int           `json:"depth,omitempty" yaml:"depth,omitempty"`
+	Filter       string        `json:"filter,omitempty" yaml:"filter,omitempty"`
+	SingleBranch bool          `json:"single_branch,omitempty" yaml:"single_branch,omitempty"`
+	Sparse       []string      `json:"sparse,omitempty" yaml:"sparse,omitempty"`
+	Path         string        `json:"path,omitempty" yaml:"path,omitempty"` // Checkout path instead of the layout's
+	Tags         []string      `json:"tags,omitempty" yaml:"tags,omitempty"` // Tags to add, see TagStore
+	Hooks        ManifestHooks `json:"hooks,omitzero" yaml:"hooks,omitempty"`
+}
+
+// ManifestHooks are shell commands run inside a repository during sync
+type ManifestHooks struct {
+	PostClone  string `json:"post_clone,omitempty" yaml:"post_clone,omitempty"`   // After it was cloned
+	PostUpdate string `json:"post_update,omitempty" yaml:"post_update,omitempty"` // After an existing checkout was updated
+}
+
+// LoadManifest reads and validates a manifest file
+func LoadManifest(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&m)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(&m); err != nil && len(bytes.TrimSpace(data)) == 0 {
+			err = fmt.Errorf("file is empty")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", file, err)
+	}
+	return &m, nil
+}
+
+// validate checks every entry and that no two entries share a checkout path
+func (m *Manifest) validate() error {
+	claimed := make(map[string]string) // Checkout path -> URL
+	for i, r := range m.Repos {
+		if r.URL == "" {
+			return fmt.Errorf("repos[%d]: url is required", i)
+		}
+		_, checkout, err := r.Resolve()
+		if err != nil {
+			return fmt.Errorf("repos[%d] (%s): %w", i, r.URL, err)
+		}
+		if r.Filter != "" && !filterPattern.MatchString(r.Filter) {
+			return fmt.Errorf("repos[%d] (%s): unsupported filter %q", i, r.URL, r.Filter)
+		}
+		if r.Depth < 0 {
+			return fmt.Errorf("repos[%d] (%s): depth must be positive", i, r.URL)
+		}
+		for _, tag := range r.Tags {
+			if err := ValidateTagName(tag); err != nil {
+				return fmt.Errorf("repos[%d] (%s): %w", i, r.URL, err)
+			}
+		}
+		if other, ok := claimed[checkout]; ok {
+			return fmt.Errorf("repos[%d] (%s): path %s is also used by %s", i, r.URL, checkout, other)
+		}
+		claimed[checkout] = r.URL
+	}
+	return nil
+}
+
+// Resolve returns the remote of the entry and its checkout path below the
+// codebases directory: the entry's path, or the one the layout gives
+func (r ManifestRepo) Resolve() (RemoteURL, string, error) {
+	return r.ResolveIn("")
+}
+
+// ResolveIn is Resolve putting the checkout into the named root, whatever
+// root its path or the roots' Match patterns name. An empty rootName leaves
+// the choice to them; a path naming no root then goes into the root the
+// remote is cloned into.
+func (r ManifestRepo) ResolveIn(rootName string) (RemoteURL, string, error) {
+	remote, err := ResolveURL(r.URL)
+	if err != nil {
+		return RemoteURL{}, "", err
+	}
+	if r.Path == "" {
+		if rootName != "" {
+			checkout, err := remote.ClonePathIn(rootName)
+			return remote, checkout, err
+		}
+		return remote, remote.ClonePath(), nil
+	}
+
+	checkout := path.Clean(filepath.ToSlash(r.Path))
+	if path.IsAbs(checkout) || filepath.IsAbs(r.Path) || checkout == "." || checkout == ".." || strings.HasPrefix(checkout, "../") {
+		return RemoteURL{}, "", fmt.Errorf("path %q must be relative to the codebases directory", r.Path)
+	}
+	if target, ok := rootFor(remote); ok {
+		pathRoot, rel := splitRoot(checkout)
+		switch {
+		case rootName != "":
+			if err := CheckRootNames([]string{rootName}); err != nil {
+				return RemoteURL{}, "", err
+			}
+			checkout = path.Join(rootName, rel)
+		case pathRoot == "":
+			checkout = path.Join(target.Name, checkout)
+		}
+	}
+	return remote, checkout, nil
+}
+
+// Options returns the clone options of the entry, with a ref given in the URL
+// and the configured clone defaults applied (see ResolveOptions)
+func (r ManifestRepo) Options(remote RemoteURL) CloneOptions {
+	return remote.ResolveOptions(CloneOptions{
+		Ref:          r.Ref,
+		Depth:        r.Depth,
+		Filter:       r.Filter,
+		SingleBranch: r.SingleBranch,
+		Sparse:       r.Sparse,
+	})
+}
+
+// Checkout moves an existing checkout to ref, as if it had been cloned at
+// it: a branch is checked out and then updated like Pull does, tags and
+// commits are checked out detached. Uncommitted changes make it skip.
+// Bare repositories and submodules, which have no ref of their own to check
+// out, are updated like Pull does.
+// If onProgress is non-nil it receives git's transfer progress.
+func (g *Git) Checkout(ctx context.Context, repoPath, ref string, strategy UpdateStrategy, onProgress ProgressFunc) GitOperation {
+	if kind, _ := DetectKind(repoPath); kind == KindBare || kind == KindSubmodule {
+		return g.Pull(ctx, repoPath, strategy, onProgress)
+	}
+
+	pullCtx := ctx
+	ctx, cancel := withTimeout(ctx, g.timeouts.Pull)
+	defer cancel()
+
+	if g.HasUncommittedChanges(ctx, repoPath) {
+		if ctx.Err() != nil {
+			return GitOperation{Success: false, Error: contextError(ctx, "checkout", g.timeouts.Pull, ctx.Err())}
+		}
+		return GitOperation{Success: false, SkipReason: SkipDirty}
+	}
+
+	fetch := g.command(ctx, "-C", repoPath, "fetch", "--progress", "--tags", "origin")
+	if _, err := g.runCommandWithProgress(fetch, onProgress); err != nil {
+		return GitOperation{Success: false, Error: contextError(ctx, "fetch", g.timeouts.Pull, err)}
+	}
+
+	verify := func(name string) bool {
+		_, err := g.runCommand(g.command(ctx, "-C", repoPath, "rev-parse", "--quiet", "--verify", name))
+		return err == nil
+	}
+
+	var err error
+	switch {
+	case verify("refs/heads/"+ref) || verify("refs/remotes/origin/"+ref):
+		// git creates the local branch tracking origin's when needed
+		switchBranch := g.command(ctx, "-C", repoPath, "checkout", "--quiet", ref, "--")
+		if _, err := g.runCommand(switchBranch); err != nil {
+			return GitOperation{Success: false, Error: contextError(ctx, "checkout", g.timeouts.Pull, err)}
+		}
+		return g.Pull(pullCtx, repoPath, strategy, onProgress)
+	case verify(ref + "^{commit}"):
+		_, err = g.runCommand(g.command(ctx, "-C", repoPath, "checkout", "--quiet", "--detach", ref))
+	default:
+		// Refs that are not fetched by default, such as pull request heads
+		if err = g.checkoutRef(ctx, repoPath, ref, 0); err != nil && ctx.Err() == nil {
+			err = fmt.Errorf("ref %q not found: no such branch, tag or commit", ref)
+		}
+	}
+	if err != nil {
+		return GitOperation{Success: false, Error: contextError(ctx, "checkout", g.timeouts.Pull, err)}
+	}
+
+	return GitOperation{
+		Success:        true,
+		Output:         "checked out " + ref,
+		SubmoduleError: g.updateSubmodules(ctx, repoPath, 0, "", onProgress),
+	}
+}
@@ -0,0 +1,247 @@
+package repo
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Partial clone filters commonly used for large repositories
+const (
+	FilterBlobless = "blob:none" // Fetch file contents on demand
+	FilterTreeless = "tree:0"    // Fetch trees and file contents on demand
+)
+
+// filterPattern accepts the object filter specs understood by git clone --filter
+var filterPattern = regexp.MustCompile(`^(blob:none|blob:limit=\d+[kmg]?|tree:\d+|sparse:oid=\S+|object:type=(blob|tree|commit|tag)|combine:\S+)$`)
+
+// CloneOptions adjusts how a repository is cloned
+type CloneOptions struct {
+	Ref          string   // Branch, tag or commit to check out; the remote's HEAD if empty
+	Depth        int      // Truncate history to this many commits; 0 for full history
+	Filter       string   // Partial clone filter, e.g. FilterBlobless
+	SingleBranch bool     // Only fetch the checked out branch
+	Sparse       []string // Directories for a cone-mode sparse checkout
+}
+
+// Merge returns o with every option set in override replacing its own
+func (o CloneOptions) Merge(override CloneOptions) CloneOptions {
+	if override.Ref != "" {
+		o.Ref = override.Ref
+	}
+	if override.Depth > 0 {
+		o.Depth = override.Depth
+	}
+	if override.Filter != "" {
+		o.Filter = override.Filter
+	}
+	if override.SingleBranch {
+		o.SingleBranch = true
+	}
+	if len(override.Sparse) > 0 {
+		o.Sparse = override.Sparse
+	}
+	return o
+}
+
+// String summarizes the options, e.g. "ref v1.2, depth 1, filter blob:none"
+func (o CloneOptions) String() string {
+	var parts []string
+	if o.Ref != "" {
+		parts = append(parts, "ref "+o.Ref)
+	}
+	if o.Depth > 0 {
+		parts = append(parts, fmt.Sprintf("depth %d", o.Depth))
+	}
+	if o.Filter != "" {
+		parts = append(parts, "filter "+o.Filter)
+	}
+	if o.SingleBranch {
+		parts = append(parts, "single branch")
+	}
+	if len(o.Sparse) > 0 {
+		parts = append(parts, "sparse "+strings.Join(o.Sparse, ","))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseCloneFlag parses a clone option at the start of args into opts and
+// returns how many arguments it used: 0 if args[0] is not a clone option,
+// 2 for "--depth 1" and 1 for "--depth=1" or "--single-branch".
+func ParseCloneFlag(args []string, opts *CloneOptions) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+
+	name, value, inline := strings.Cut(args[0], "=")
+	used := 1
+	switch name {
+	case "--single-branch":
+		if inline {
+			return 0, fmt.Errorf("--single-branch does not take a value")
+		}
+		opts.SingleBranch = true
+		return 1, nil
+	case "--ref", "--depth", "--filter", "--sparse":
+		if !inline {
+			if len(args) < 2 {
+				return 0, fmt.Errorf("%s requires a value", name)
+			}
+			value = args[1]
+			used = 2
+		}
+	default:
+		return 0, nil
+	}
+
+	switch name {
+	case "--ref":
+		if value == "" {
+			return 0, fmt.Errorf("--ref requires a branch, tag or commit")
+		}
+		opts.Ref = value
+	case "--depth":
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 1 {
+			return 0, fmt.Errorf("--depth requires a positive number, got %q", value)
+		}
+		opts.Depth = depth
+	case "--filter":
+		if !filterPattern.MatchString(value) {
+			return 0, fmt.Errorf("unsupported --filter %q (e.g. %s or %s)", value, FilterBlobless, FilterTreeless)
+		}
+		opts.Filter = value
+	case "--sparse":
+		dirs, err := parseSparseDirs(value)
+		if err != nil {
+			return 0, err
+		}
+		opts.Sparse = append(opts.Sparse, dirs...)
+	}
+	return used, nil
+}
+
+// ParseCloneSpec parses a URL followed by clone options, as written on a
+// line of a clone file or in the TUI: "gh:org/mono --depth 1 --sparse api"
+func ParseCloneSpec(spec string) (string, CloneOptions, error) {
+	var opts CloneOptions
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return "", opts, fmt.Errorf("empty URL provided")
+	}
+	if strings.HasPrefix(fields[0], "-") {
+		return "", opts, fmt.Errorf("expected a URL before %s", fields[0])
+	}
+
+	for i := 1; i < len(fields); {
+		used, err := ParseCloneFlag(fields[i:], &opts)
+		if err != nil {
+			return "", opts, err
+		}
+		if used == 0 {
+			return "", opts, fmt.Errorf("unknown clone option %q", fields[i])
+		}
+		i += used
+	}
+	return fields[0], opts, nil
+}
+
+// parseSparseDirs splits a comma-separated list of sparse checkout directories
+func parseSparseDirs(value string) ([]string, error) {
+	var dirs []string
+	for _, dir := range strings.Split(value, ",") {
+		dir = strings.Trim(strings.TrimSpace(dir), "/")
+		if dir == "" {
+			continue
+		}
+		if dir == ".." || strings.HasPrefix(dir, "../") || strings.Contains(dir, "/../") {
+			return nil, fmt.Errorf("sparse directory %q must stay inside the repository", dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("--sparse requires at least one directory")
+	}
+	return dirs, nil
+}
+
+// CloneDefault applies clone options to repositories matching a pattern.
+// A pattern without "/" matches hosts ("github.com", "*.corp.example");
+// otherwise it is matched against host and path ("github.com/bigcorp/*").
+type CloneDefault struct {
+	Match   string
+	Options CloneOptions
+}
+
+var (
+	cloneDefaultsMu sync.RWMutex
+	cloneDefaults   []CloneDefault
+)
+
+// SetCloneDefaults registers per-host and per-pattern clone defaults.
+// When several match, later entries override earlier ones.
+func SetCloneDefaults(defaults []CloneDefault) error {
+	validated := make([]CloneDefault, 0, len(defaults))
+	for _, d := range defaults {
+		d.Match = strings.ToLower(strings.Trim(strings.TrimSpace(d.Match), "/"))
+		if d.Match == "" {
+			return fmt.Errorf("clone default has no match pattern")
+		}
+		if _, err := path.Match(d.Match, ""); err != nil {
+			return fmt.Errorf("clone default %q: invalid pattern: %w", d.Match, err)
+		}
+		if d.Options.Depth < 0 {
+			return fmt.Errorf("clone default %q: depth must not be negative", d.Match)
+		}
+		if d.Options.Filter != "" && !filterPattern.MatchString(d.Options.Filter) {
+			return fmt.Errorf("clone default %q: unsupported filter %q", d.Match, d.Options.Filter)
+		}
+		if d.Options.Ref != "" {
+			return fmt.Errorf("clone default %q: a ref cannot be set as a default", d.Match)
+		}
+		validated = append(validated, d)
+	}
+
+	cloneDefaultsMu.Lock()
+	cloneDefaults = validated
+	cloneDefaultsMu.Unlock()
+	return nil
+}
+
+// RequestedRef returns the ref asked for in the URL or in opts. Refs from the
+// configured clone defaults only apply to new clones, so they are left out.
+func (u RemoteURL) RequestedRef(opts CloneOptions) string {
+	if u.Ref != "" {
+		return u.Ref
+	}
+	return opts.Ref
+}
+
+// ResolveOptions combines the configured defaults for the repository, the
+// explicitly requested options and a ref given in the URL, in increasing
+// order of precedence
+func (u RemoteURL) ResolveOptions(opts CloneOptions) CloneOptions {
+	cloneDefaultsMu.RLock()
+	var resolved CloneOptions
+	key := strings.ToLower(u.Key())
+	host, _, _ := strings.Cut(key, "/")
+	for _, d := range cloneDefaults {
+		subject := key
+		if !strings.Contains(d.Match, "/") {
+			subject = host
+		}
+		if matched, _ := path.Match(d.Match, subject); matched {
+			resolved = resolved.Merge(d.Options)
+		}
+	}
+	cloneDefaultsMu.RUnlock()
+
+	resolved = resolved.Merge(opts)
+	if u.Ref != "" {
+		resolved.Ref = u.Ref
+	}
+	return resolved
+}
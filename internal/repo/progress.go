@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Progress represents a single progress update reported by git
+type Progress struct {
+	Phase   string // e.g. "Receiving objects", "Resolving deltas"
+	Percent int    // Percentage of the current phase (0-100)
+	Current int    // Objects processed so far in the current phase
+	Total   int    // Total objects in the current phase
+	Detail  string // Trailing detail such as transfer size and rate
+}
+
+// ProgressFunc receives progress updates while a git command runs.
+// It is called from the goroutine executing the command.
+type ProgressFunc func(Progress)
+
+// phaseWeights maps the network-bound git phases onto a share of the overall
+// operation so that a single progress bar can advance smoothly across phases.
+// Each entry is the [start, end) range of the overall fraction.
+var phaseWeights = map[string][2]float64{
+	"Enumerating objects": {0.00, 0.02},
+	"Counting objects":    {0.02, 0.05},
+	"Compressing objects": {0.05, 0.10},
+	"Receiving objects":   {0.10, 0.80},
+	"Resolving deltas":    {0.80, 0.95},
+	"Updating files":      {0.95, 1.00},
+}
+
+// Overall returns the estimated completion of the whole operation (0.0-1.0)
+func (p Progress) Overall() float64 {
+	weight, ok := phaseWeights[p.Phase]
+	if !ok {
+		return float64(p.Percent) / 100
+	}
+	return weight[0] + (weight[1]-weight[0])*float64(p.Percent)/100
+}
+
+// String renders the progress in the same shape git prints it
+func (p Progress) String() string {
+	s := p.Phase + ": " + strconv.Itoa(p.Percent) + "%"
+	if p.Total > 0 {
+		s += " (" + strconv.Itoa(p.Current) + "/" + strconv.Itoa(p.Total) + ")"
+	}
+	if p.Detail != "" {
+		s += ", " + p.Detail
+	}
+	return s
+}
+
+// progressPattern matches git progress lines such as
+// "Receiving objects:  45% (450/1000), 1.20 MiB | 2.00 MiB/s"
+// and the "remote: "-prefixed variants sent by the server.
+var progressPattern = regexp.MustCompile(`^(?:remote:\s*)?([A-Za-z][A-Za-z ]*?):\s+(\d+)%\s*(?:\((\d+)/(\d+)\))?(?:,\s*(.*))?$`)
+
+// ParseProgressLine parses a single git progress line
+func ParseProgressLine(line string) (Progress, bool) {
+	line = strings.TrimSuffix(strings.TrimSpace(line), ", done.")
+	match := progressPattern.FindStringSubmatch(line)
+	if match == nil {
+		return Progress{}, false
+	}
+
+	percent, _ := strconv.Atoi(match[2])
+	current, _ := strconv.Atoi(match[3])
+	total, _ := strconv.Atoi(match[4])
+
+	return Progress{
+		Phase:   match[1],
+		Percent: percent,
+		Current: current,
+		Total:   total,
+		Detail:  strings.TrimSpace(match[5]),
+	}, true
+}
+
+// progressWriter consumes git's stderr, reporting progress lines to a callback
+// and keeping every other line so that errors can still be surfaced.
+// Git separates progress updates with carriage returns, so both '\r' and '\n'
+// terminate a line.
+type progressWriter struct {
+	mu         sync.Mutex
+	onProgress ProgressFunc
+	pending    []byte
+	output     bytes.Buffer
+}
+
+func newProgressWriter(onProgress ProgressFunc) *progressWriter {
+	return &progressWriter{onProgress: onProgress}
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	for {
+		idx := bytes.IndexAny(w.pending, "\r\n")
+		if idx == -1 {
+			break
+		}
+		w.handleLine(string(w.pending[:idx]))
+		w.pending = w.pending[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *progressWriter) handleLine(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	if progress, ok := ParseProgressLine(line); ok {
+		if w.onProgress != nil {
+			w.onProgress(progress)
+		}
+		return
+	}
+
+	w.output.WriteString(line)
+	w.output.WriteByte('\n')
+}
+
+// String returns the non-progress output collected so far
+func (w *progressWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) > 0 {
+		w.handleLine(string(w.pending))
+		w.pending = nil
+	}
+	return w.output.String()
+}
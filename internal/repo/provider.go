@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Protocols a provider can prefer when expanding short notation
+const (
+	ProtocolHTTPS = "https"
+	ProtocolSSH   = "ssh"
+)
+
+// Provider describes a git host reachable through short notation,
+// e.g. "gh:user/repo" or "corp:team/service"
+type Provider struct {
+	Name         string   // Full prefix, also used for fuzzy matching (e.g. "github")
+	Aliases      []string // Exact-match abbreviations (e.g. "gh")
+	Host         string   // Host name, optionally with port (e.g. "github.com")
+	Protocol     string   // ProtocolHTTPS (default) or ProtocolSSH
+	DefaultOwner string   // Owner used when the shorthand names only a repository
+	Web          string   // Style of the host's browser links: WebGitHub, WebGitea, WebBitbucket or WebGitLab
+	Custom       bool     // Declared in the configuration rather than built in
+}
+
+// builtinProviders are always available. The "git" alias defaults to GitHub
+// as it is the most common host.
+var builtinProviders = []Provider{
+	{Name: "github", Aliases: []string{"gh", "git"}, Host: "github.com", Web: WebGitHub},
+	{Name: "gitlab", Aliases: []string{"gl"}, Host: "gitlab.com", Web: WebGitLab},
+	{Name: "bitbucket", Aliases: []string{"bb"}, Host: "bitbucket.org", Web: WebBitbucket},
+}
+
+var (
+	providersMu     sync.RWMutex
+	customProviders []Provider
+)
+
+// SetProviders registers user-defined providers. They take precedence over
+// the built-in ones, so a custom provider may reuse a built-in name or alias.
+func SetProviders(providers []Provider) error {
+	validated := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		p.Name = strings.ToLower(strings.TrimSpace(p.Name))
+		p.Protocol = strings.ToLower(p.Protocol)
+		p.Web = strings.ToLower(p.Web)
+		p.Custom = true
+
+		if p.Name == "" || strings.ContainsAny(p.Name, ":/@ ") {
+			return fmt.Errorf("invalid provider name %q", p.Name)
+		}
+		if p.Host == "" {
+			return fmt.Errorf("provider %q has no host", p.Name)
+		}
+		if p.Protocol != "" && p.Protocol != ProtocolHTTPS && p.Protocol != ProtocolSSH {
+			return fmt.Errorf("provider %q: unsupported protocol %q (use %q or %q)",
+				p.Name, p.Protocol, ProtocolHTTPS, ProtocolSSH)
+		}
+		switch p.Web {
+		case "", WebGitHub, WebGitea, WebBitbucket, WebGitLab:
+		default:
+			return fmt.Errorf("provider %q: unknown web style %q (use %q, %q, %q or %q)",
+				p.Name, p.Web, WebGitHub, WebGitea, WebBitbucket, WebGitLab)
+		}
+		aliases := make([]string, 0, len(p.Aliases))
+		for _, alias := range p.Aliases {
+			aliases = append(aliases, strings.ToLower(strings.TrimSpace(alias)))
+		}
+		p.Aliases = aliases
+		validated = append(validated, p)
+	}
+
+	providersMu.Lock()
+	customProviders = validated
+	providersMu.Unlock()
+	return nil
+}
+
+// Providers returns every known provider, custom ones first
+func Providers() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	all := make([]Provider, 0, len(customProviders)+len(builtinProviders))
+	all = append(all, customProviders...)
+	all = append(all, builtinProviders...)
+	return all
+}
+
+// Prefixes returns the names and aliases a provider answers to
+func (p Provider) Prefixes() []string {
+	return append([]string{p.Name}, p.Aliases...)
+}
+
+// Expand builds the clone URL for a repository path on this provider
+func (p Provider) Expand(path string) string {
+	if p.DefaultOwner != "" && !strings.Contains(strings.Trim(path, "/"), "/") {
+		path = p.DefaultOwner + "/" + strings.Trim(path, "/")
+	}
+
+	if p.Protocol == ProtocolSSH {
+		// The scp-like form cannot carry a port
+		if strings.Contains(p.Host, ":") {
+			return fmt.Sprintf("ssh://git@%s/%s", p.Host, path)
+		}
+		return fmt.Sprintf("git@%s:%s", p.Host, path)
+	}
+	return fmt.Sprintf("https://%s/%s", p.Host, path)
+}
+
+// findProvider resolves a shorthand prefix. Exact names and aliases win;
+// otherwise a prefix that starts exactly one provider name is accepted.
+func findProvider(prefix string) (Provider, bool) {
+	providers := Providers()
+
+	for _, p := range providers {
+		for _, name := range p.Prefixes() {
+			if prefix == name {
+				return p, true
+			}
+		}
+	}
+
+	var matches []Provider
+	seen := make(map[string]bool)
+	for _, p := range providers {
+		if strings.HasPrefix(p.Name, prefix) && !seen[p.Name] {
+			seen[p.Name] = true
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+
+	return Provider{}, false
+}
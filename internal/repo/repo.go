@@ -1,10 +1,9 @@
 package repo
 
 import (
+	"errors"
 	"fmt"
 	"get-repo/internal/debug"
-	"io/fs"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,125 +15,57 @@ type Repository struct {
 	Path     string
 	URL      string
 	IsGitDir bool
+	Kind     Kind // How the repository is laid out, only set when IsGitDir
 }
 
 // Manager handles repository operations
 type Manager struct {
 	basePath string
+	index    *Index        // Cached scan result, nil to always scan
+	roots    []rootManager // One manager per root when roots are installed
 }
 
-// NewManager creates a new repository manager
-func NewManager(basePath string) *Manager {
-	return &Manager{basePath: basePath}
+// rootManager manages the repositories of one root
+type rootManager struct {
+	name string
+	*Manager
 }
 
-// List returns all directories and repositories found under the base path
-func (m *Manager) List() ([]Repository, error) {
-	defer debug.LogFunction("Manager.List")()
-	debug.Log("Scanning base path: %s", m.basePath)
-
-	var repos []Repository
-	visited := make(map[string]bool) // Track visited paths to avoid duplicates
-
-	// Check if base path exists
-	if _, err := os.Stat(m.basePath); os.IsNotExist(err) {
-		debug.Log("Base path does not exist: %s", m.basePath)
-		return nil, fmt.Errorf("base path does not exist: %s", m.basePath)
+// NewManager creates a new repository manager for basePath. With roots
+// installed (see SetRoots) it manages all of them instead, and repository
+// names start with the name of their root.
+func NewManager(basePath string) *Manager {
+	m := &Manager{basePath: basePath}
+	for _, r := range installedRoots() {
+		m.roots = append(m.roots, rootManager{name: r.Name, Manager: &Manager{basePath: r.Path}})
 	}
+	return m
+}
 
-	// First pass: Find all git repositories
-	debug.Log("First pass: scanning for git repositories...")
-	err := filepath.WalkDir(m.basePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			debug.LogError(err, fmt.Sprintf("walking path %s", path))
-			return err
-		}
-
-		if d.IsDir() && d.Name() == ".git" {
-			repoPath := filepath.Dir(path)
-			relPath, err := filepath.Rel(m.basePath, repoPath)
-			if err != nil {
-				debug.LogError(err, fmt.Sprintf("getting relative path for %s", repoPath))
-				return err
-			}
-
-			debug.Log("Found git repository: %s", relPath)
-			repos = append(repos, Repository{
-				Name:     relPath,
-				Path:     repoPath,
-				IsGitDir: true,
-			})
-			visited[relPath] = true
-
-			return filepath.SkipDir
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		debug.LogError(err, "first pass walkdir")
-		return nil, err
+// resolve returns the manager of the root a repository name is in and the
+// name inside that root. Names in no configured root give a nil manager.
+func (m *Manager) resolve(repoName string) (*Manager, string) {
+	if len(m.roots) == 0 {
+		return m, repoName
 	}
-	debug.Log("First pass complete, found %d git repositories", len(repos))
-
-	// Second pass: Find all directories (including organizational folders)
-	debug.Log("Second pass: scanning for organizational directories...")
-	err = filepath.WalkDir(m.basePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			debug.LogError(err, fmt.Sprintf("walking path %s in second pass", path))
-			return err
-		}
-
-		// Skip the base path itself
-		if path == m.basePath {
-			return nil
-		}
-
-		if d.IsDir() && d.Name() != ".git" {
-			relPath, err := filepath.Rel(m.basePath, path)
-			if err != nil {
-				debug.LogError(err, fmt.Sprintf("getting relative path for %s in second pass", path))
-				return err
-			}
-
-			// Skip if we already found this as a git repository
-			if visited[relPath] {
-				debug.Log("Skipping already visited path: %s", relPath)
-				return filepath.SkipDir
-			}
-
-			// Skip hidden directories and nested paths of git repos
-			if strings.HasPrefix(d.Name(), ".") {
-				debug.Log("Skipping hidden directory: %s", relPath)
-				return filepath.SkipDir
-			}
-
-			// Only show top-level directories and direct subdirectories
-			pathParts := strings.Split(relPath, string(filepath.Separator))
-			if len(pathParts) <= 2 { // e.g., "gitlab.com" or "github.com/user"
-				debug.Log("Found organizational directory: %s", relPath)
-				repos = append(repos, Repository{
-					Name:     relPath,
-					Path:     path,
-					IsGitDir: false,
-				})
-				visited[relPath] = true
-			}
+	first, rest, _ := strings.Cut(filepath.ToSlash(repoName), "/")
+	for _, r := range m.roots {
+		if r.name == first {
+			return r.Manager, filepath.FromSlash(rest)
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		debug.LogError(err, "second pass walkdir")
 	}
-	debug.Log("Repository scan complete, total found: %d", len(repos))
-	return repos, err
+	return nil, ""
 }
 
-// ExpandShortNotation expands short notation like gh:user/repo to full URLs
+// ExpandShortNotation expands short notation like gh:user/repo to full URLs.
+// Both built-in and configured providers are considered (see SetProviders).
+// URL rewrite rules are not applied here; see RemoteURL.CloneURL.
 func ExpandShortNotation(input string) string {
+	// Full URLs are never short notation
+	if strings.Contains(input, "://") {
+		return input
+	}
+
 	// Check if input contains colon for short notation
 	colonIndex := strings.Index(input, ":")
 	if colonIndex == -1 || colonIndex == 0 {
@@ -145,112 +76,157 @@ func ExpandShortNotation(input string) string {
 	prefix := strings.ToLower(input[:colonIndex])
 	path := input[colonIndex+1:]
 
-	// Define known providers
-	providers := map[string]string{
-		"github":    "github.com",
-		"gitlab":    "gitlab.com",
-		"bitbucket": "bitbucket.org",
-	}
-
-	// Try exact match first
-	for name, domain := range providers {
-		if prefix == name {
-			return fmt.Sprintf("https://%s/%s", domain, path)
-		}
-	}
-
-	// Fuzzy match - find the provider that starts with the prefix
-	var matches []string
-	var matchedDomains []string
-	
-	for name, domain := range providers {
-		if strings.HasPrefix(name, prefix) {
-			matches = append(matches, name)
-			matchedDomains = append(matchedDomains, domain)
-		}
-	}
-
-	// If exactly one match, use it
-	if len(matches) == 1 {
-		return fmt.Sprintf("https://%s/%s", matchedDomains[0], path)
-	}
-
-	// If no matches or multiple matches, try common abbreviations
-	commonAbbreviations := map[string]string{
-		"gh": "github.com",
-		"gl": "gitlab.com",
-		"bb": "bitbucket.org",
-		"git": "github.com", // Default "git" to GitHub as it's most common
-	}
-
-	if domain, ok := commonAbbreviations[prefix]; ok {
-		return fmt.Sprintf("https://%s/%s", domain, path)
+	if provider, ok := findProvider(prefix); ok {
+		return provider.Expand(path)
 	}
 
 	// Return input unchanged if no clear match
 	return input
 }
 
-// ValidateURL validates and normalizes a git repository URL
+// ValidateURL validates a git repository URL, expanding short notation first
 func ValidateURL(rawURL string) error {
 	if rawURL == "" {
 		return fmt.Errorf("empty URL provided")
 	}
 
-	// Expand short notation first
-	expandedURL := ExpandShortNotation(rawURL)
+	_, err := ResolveURL(rawURL)
+	return err
+}
 
-	// Handle SSH URLs
-	if strings.HasPrefix(expandedURL, "git@") {
-		return nil
+// GetClonePath derives the local filesystem path from a git URL, expanding
+// short notation first. It returns "" for URLs that do not parse; validate
+// them with ValidateURL beforehand.
+func GetClonePath(gitURL string) string {
+	remote, err := ResolveURL(gitURL)
+	if err != nil {
+		return ""
 	}
+	return remote.ClonePath()
+}
 
-	// Handle HTTP(S) URLs
-	if strings.HasPrefix(expandedURL, "http://") || strings.HasPrefix(expandedURL, "https://") {
-		_, err := url.Parse(expandedURL)
-		if err != nil {
-			return fmt.Errorf("invalid URL: %w", err)
-		}
-		return nil
+// OpenIndex loads the repository index kept at path and makes List answer
+// from it while the directories it was built from are unchanged. Every full
+// scan rebuilds it. Each root keeps its own index next to path, e.g.
+// "index-work.json" for the root "work".
+func (m *Manager) OpenIndex(path string) {
+	if len(m.roots) == 0 {
+		m.setIndex(LoadIndex(path, m.basePath))
+		return
 	}
-
-	// Handle SCP-style URLs
-	if strings.Contains(expandedURL, ":") && !strings.Contains(expandedURL, "://") {
-		return nil
+	for _, r := range m.roots {
+		rootPath := ""
+		if path != "" {
+			ext := filepath.Ext(path)
+			rootPath = strings.TrimSuffix(path, ext) + "-" + r.name + ext
+		}
+		r.setIndex(LoadIndex(rootPath, r.basePath))
 	}
-
-	return fmt.Errorf("unsupported URL format: %s", expandedURL)
 }
 
-// GetClonePath derives the local filesystem path from a git URL
-func GetClonePath(gitURL string) string {
-	// Expand short notation first
-	path := ExpandShortNotation(gitURL)
-
-	// Remove protocol prefixes
-	path = strings.TrimPrefix(path, "https://")
-	path = strings.TrimPrefix(path, "http://")
-	path = strings.TrimPrefix(path, "git@")
+// setIndex installs the index of a single base path
+func (m *Manager) setIndex(ix *Index) {
+	m.index = ix
+	// Repositories cloned into ignored directories stay out of the index
+	ix.mu.Lock()
+	ix.excluded = m.excluded
+	ix.mu.Unlock()
+}
 
-	// Handle SSH URLs (git@host:user/repo)
-	if strings.Contains(path, ":") && !strings.Contains(path, "://") {
-		path = strings.Replace(path, ":", "/", 1)
+// RecordFetch updates the index entry of a repository that was just cloned,
+// updated or fetched (see Index.RecordFetch)
+func (m *Manager) RecordFetch(repoName string) {
+	if rm, name := m.resolve(repoName); rm != nil && rm.index != nil {
+		rm.index.RecordFetch(name)
 	}
+}
 
-	// Remove .git suffix
-	path = strings.TrimSuffix(path, ".git")
+// RecordRemoval drops a deleted repository from the index
+func (m *Manager) RecordRemoval(repoName string) {
+	if rm, name := m.resolve(repoName); rm != nil && rm.index != nil {
+		rm.index.Remove(name)
+	}
+}
 
-	return path
+// SaveIndex writes the changes recorded since the index was loaded
+func (m *Manager) SaveIndex() error {
+	if len(m.roots) == 0 {
+		if m.index == nil {
+			return nil
+		}
+		return m.index.Save()
+	}
+	var errs []error
+	for _, r := range m.roots {
+		errs = append(errs, r.SaveIndex())
+	}
+	return errors.Join(errs...)
 }
 
 // PathExists checks if a repository path already exists
 func (m *Manager) PathExists(repoName string) bool {
-	fullPath := filepath.Join(m.basePath, repoName)
+	fullPath := m.GetFullPath(repoName)
+	if fullPath == "" {
+		return false
+	}
 	_, err := os.Stat(fullPath)
 	return err == nil
 }
 
-// GetFullPath returns the full filesystem path for a repository
+// GetFullPath returns the full filesystem path for a repository, or "" for
+// a name in no configured root
 func (m *Manager) GetFullPath(repoName string) string {
-	return filepath.Join(m.basePath, repoName)
+	rm, name := m.resolve(repoName)
+	if rm == nil {
+		return ""
+	}
+	return filepath.Join(rm.basePath, name)
+}
+
+// Move relocates a repository to another path below the base path, creating
+// parent directories as needed and removing those left empty behind it.
+// With roots, both paths must be in the same root.
+func (m *Manager) Move(from, to string) error {
+	if len(m.roots) > 0 {
+		fromRoot, fromName := m.resolve(from)
+		toRoot, toName := m.resolve(to)
+		if fromRoot == nil || toRoot == nil || fromRoot != toRoot {
+			return fmt.Errorf("cannot move %s to %s: not in the same root", from, to)
+		}
+		return fromRoot.Move(fromName, toName)
+	}
+
+	source := m.GetFullPath(from)
+	target := m.GetFullPath(to)
+
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("%s already exists", to)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(to), err)
+	}
+	if err := os.Rename(source, target); err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+
+	m.removeEmptyParents(filepath.Dir(source))
+	if m.index != nil {
+		m.index.Remove(from)
+		m.index.Record(to)
+	}
+	return nil
+}
+
+// removeEmptyParents deletes dir and its ancestors while they are empty,
+// stopping at the base path
+func (m *Manager) removeEmptyParents(dir string) {
+	base := filepath.Clean(m.basePath)
+	for dir != base && strings.HasPrefix(dir, base+string(filepath.Separator)) {
+		if err := os.Remove(dir); err != nil {
+			// Not empty (or not removable); leave it and everything above
+			return
+		}
+		debug.Log("Removed empty directory: %s", dir)
+		dir = filepath.Dir(dir)
+	}
 }
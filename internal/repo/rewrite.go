@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// RewriteRule changes the URL used to talk to a remote, much like git's
+// url.<base>.insteadOf. A rule either switches the protocol for matching
+// hosts (Host + Protocol) or replaces a URL prefix (URL + InsteadOf).
+// Rewrites never change where a repository is checked out.
+type RewriteRule struct {
+	Host      string // Host name or glob, e.g. "github.com" or "*.corp.example"
+	Protocol  string // ProtocolHTTPS or ProtocolSSH
+	URL       string // Replacement prefix, e.g. "git@github.com:"
+	InsteadOf string // Prefix to replace, e.g. "https://github.com/"
+}
+
+var (
+	rewriteMu    sync.RWMutex
+	rewriteRules []RewriteRule
+)
+
+// SetRewriteRules registers URL rewrite rules, replacing any previous ones
+func SetRewriteRules(rules []RewriteRule) error {
+	validated := make([]RewriteRule, 0, len(rules))
+	for _, r := range rules {
+		r.Host = strings.ToLower(strings.TrimSpace(r.Host))
+		r.Protocol = strings.ToLower(strings.TrimSpace(r.Protocol))
+
+		switch {
+		case r.Host != "" && r.InsteadOf == "":
+			if r.Protocol != ProtocolHTTPS && r.Protocol != ProtocolSSH {
+				return fmt.Errorf("rewrite for host %q: protocol must be %q or %q",
+					r.Host, ProtocolHTTPS, ProtocolSSH)
+			}
+			if _, err := path.Match(r.Host, ""); err != nil {
+				return fmt.Errorf("rewrite for host %q: invalid pattern: %w", r.Host, err)
+			}
+		case r.Host == "" && r.InsteadOf != "":
+			if r.URL == "" {
+				return fmt.Errorf("rewrite of %q has no replacement url", r.InsteadOf)
+			}
+		default:
+			return fmt.Errorf("rewrite rule needs either host and protocol, or url and instead_of")
+		}
+		validated = append(validated, r)
+	}
+
+	rewriteMu.Lock()
+	rewriteRules = validated
+	rewriteMu.Unlock()
+	return nil
+}
+
+// CloneURL returns the URL git should use for the remote after applying the
+// rewrite rules. Protocol rules apply first; then the longest matching
+// instead_of prefix is replaced, as git does.
+func (u RemoteURL) CloneURL() string {
+	rewriteMu.RLock()
+	defer rewriteMu.RUnlock()
+
+	if !u.Local {
+		for _, r := range rewriteRules {
+			if r.Host == "" {
+				continue
+			}
+			if matched, _ := path.Match(r.Host, u.Host); matched {
+				u = u.withProtocol(r.Protocol)
+				break
+			}
+		}
+	}
+
+	raw := u.String()
+	best := -1
+	for i, r := range rewriteRules {
+		if r.InsteadOf != "" && strings.HasPrefix(raw, r.InsteadOf) &&
+			(best == -1 || len(r.InsteadOf) > len(rewriteRules[best].InsteadOf)) {
+			best = i
+		}
+	}
+	if best != -1 {
+		raw = rewriteRules[best].URL + strings.TrimPrefix(raw, rewriteRules[best].InsteadOf)
+	}
+	return raw
+}
+
+// withProtocol converts a remote to HTTPS or scp-like SSH on the same host.
+// Ports are dropped since they rarely carry over between protocols.
+func (u RemoteURL) withProtocol(protocol string) RemoteURL {
+	switch protocol {
+	case ProtocolSSH:
+		if u.Scheme == SchemeSSH {
+			return u
+		}
+		u.Scheme = SchemeSSH
+		u.SCPLike = true
+		u.User = url.User("git")
+	case ProtocolHTTPS:
+		if u.Scheme == SchemeHTTPS {
+			return u
+		}
+		u.Scheme = SchemeHTTPS
+		u.SCPLike = false
+		u.User = nil
+	default:
+		return u
+	}
+	u.Port = ""
+	u.absolute = false
+	return u
+}
@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultRootName is the root the codebases directory becomes when other
+// roots are configured next to it
+const DefaultRootName = "default"
+
+// Root is one of several directories repositories are kept in, such as
+// work code on an encrypted volume next to open-source checkouts. The names
+// of its repositories start with the root name, e.g. "work/github.com/corp/api".
+type Root struct {
+	Name   string   // First part of the names of its repositories
+	Path   string   // Directory on disk
+	Layout Layout   // Clone layout inside the root; the zero Layout uses the one installed with SetLayout
+	Match  []string // Hosts or host/path globs cloned into this root, e.g. "git.corp.example" or "github.com/corp"
+}
+
+// root is an installed Root with its layout template
+type root struct {
+	Root
+	tmpl *template.Template // nil to use the installed layout
+}
+
+var (
+	rootsMu sync.RWMutex
+	roots   []root
+)
+
+// SetRoots installs the roots repositories are kept in. Clones go to the
+// first root whose Match patterns match the remote, or else to the first
+// root. Without roots everything lives in the single base path given to
+// NewManager and names have no root part.
+func SetRoots(rs []Root) error {
+	installed := make([]root, 0, len(rs))
+	seen := make(map[string]bool)
+	for _, r := range rs {
+		switch {
+		case r.Name == "":
+			return fmt.Errorf("root for %s has no name", r.Path)
+		case r.Name == "." || r.Name == ".." || strings.ContainsAny(r.Name, `/\`) || strings.HasPrefix(r.Name, "@"):
+			return fmt.Errorf("invalid root name %q", r.Name)
+		case seen[r.Name]:
+			return fmt.Errorf("duplicate root name %q", r.Name)
+		case r.Path == "":
+			return fmt.Errorf("root %q has no path", r.Name)
+		}
+		seen[r.Name] = true
+		r.Path = filepath.Clean(r.Path)
+
+		match := make([]string, 0, len(r.Match))
+		for _, pattern := range r.Match {
+			cleaned := strings.ToLower(strings.Trim(strings.TrimSpace(pattern), "/"))
+			if _, err := path.Match(cleaned, ""); err != nil || cleaned == "" {
+				return fmt.Errorf("root %q: invalid match pattern %q", r.Name, pattern)
+			}
+			match = append(match, cleaned)
+		}
+		r.Match = match
+
+		installed = append(installed, root{Root: r})
+		if r.Layout.Template != "" || r.Layout.Lowercase || len(r.Layout.HostAliases) > 0 {
+			layout, tmpl, err := compileLayout(r.Layout)
+			if err != nil {
+				return fmt.Errorf("root %q: %w", r.Name, err)
+			}
+			installed[len(installed)-1].Layout = layout
+			installed[len(installed)-1].tmpl = tmpl
+		}
+	}
+
+	rootsMu.Lock()
+	roots = installed
+	rootsMu.Unlock()
+	return nil
+}
+
+// installedRoots returns the roots installed with SetRoots
+func installedRoots() []root {
+	rootsMu.RLock()
+	defer rootsMu.RUnlock()
+	return roots
+}
+
+// RootNames returns the names of the installed roots in order, or nil
+// without roots
+func RootNames() []string {
+	var names []string
+	for _, r := range installedRoots() {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// CheckRootNames reports an error for names that are not installed roots
+func CheckRootNames(names []string) error {
+	installed := RootNames()
+	for _, name := range names {
+		if slices.Contains(installed, name) {
+			continue
+		}
+		if len(installed) == 0 {
+			return fmt.Errorf("unknown root %q: no roots are configured", name)
+		}
+		return fmt.Errorf("unknown root %q (configured: %s)", name, strings.Join(installed, ", "))
+	}
+	return nil
+}
+
+// RootOf returns the root a repository name is in, or "" without roots
+func RootOf(name string) string {
+	rootName, _ := splitRoot(filepath.ToSlash(name))
+	return rootName
+}
+
+// splitRoot splits a slash-separated repository name into its root and the
+// path inside it. Without roots, or for a name outside every root, root is
+// "" and rel the whole name.
+func splitRoot(name string) (rootName, rel string) {
+	first, rest, _ := strings.Cut(name, "/")
+	for _, r := range installedRoots() {
+		if r.Name == first {
+			return first, rest
+		}
+	}
+	return "", name
+}
+
+// matches reports whether the root takes clones of the remote with key,
+// its host followed by its path
+func (r root) matches(key string) bool {
+	host, _, _ := strings.Cut(key, "/")
+	for _, pattern := range r.Match {
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := path.Match(pattern, host); matched {
+				return true
+			}
+			continue
+		}
+		// An owner also takes the repositories below it
+		for subject := key; subject != "." && subject != ""; subject = path.Dir(subject) {
+			if matched, _ := path.Match(pattern, subject); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clonePath returns the name of the remote's checkout in the root
+func (r root) clonePath(u RemoteURL) string {
+	var p string
+	if r.tmpl != nil {
+		p = layoutPath(u, r.Layout, r.tmpl)
+	} else {
+		layoutMu.RLock()
+		p = layoutPath(u, layout, layoutTemplate)
+		layoutMu.RUnlock()
+	}
+	return r.Name + "/" + p
+}
+
+// rootFor returns the root the remote is cloned into, if roots are installed
+func rootFor(u RemoteURL) (root, bool) {
+	installed := installedRoots()
+	if len(installed) == 0 {
+		return root{}, false
+	}
+	key := strings.ToLower(u.Key())
+	for _, r := range installed {
+		if r.matches(key) {
+			return r, true
+		}
+	}
+	return installed[0], true
+}
+
+// ClonePathIn is ClonePath in the named root, regardless of the roots'
+// Match patterns
+func (u RemoteURL) ClonePathIn(rootName string) (string, error) {
+	for _, r := range installedRoots() {
+		if r.Name == rootName {
+			return r.clonePath(u), nil
+		}
+	}
+	return "", CheckRootNames([]string{rootName})
+}
+
+// relativeToRoot returns the slash-separated path of repoPath inside the
+// root that contains it
+func relativeToRoot(repoPath string) (string, bool) {
+	for _, r := range installedRoots() {
+		if rel, err := filepath.Rel(r.Path, repoPath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,521 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"get-repo/internal/debug"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScanPolicy limits which directories Manager.Scan looks into
+type ScanPolicy struct {
+	MaxDepth int      // Directory levels below the base path to search, 0 for no limit
+	Ignore   []string // Directory globs, matched against the name or the path below the base path
+	Nested   bool     // Also search working trees for repositories that are not submodules
+}
+
+// defaultScanIgnore are directories that hold dependencies or build output
+// rather than checkouts. Repositories themselves are never entered.
+var defaultScanIgnore = []string{"node_modules"}
+
+// organizationDepth is how deep directories without a repository of their
+// own are listed, e.g. "github.com" and "github.com/user"
+const organizationDepth = 2
+
+var (
+	scanMu     sync.RWMutex
+	scanPolicy = ScanPolicy{Ignore: defaultScanIgnore}
+)
+
+// SetScanPolicy installs the policy used by Manager.Scan and Manager.List.
+// The default ignores are kept in addition to p.Ignore.
+func SetScanPolicy(p ScanPolicy) error {
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("max depth must not be negative")
+	}
+	ignore := slices.Clone(defaultScanIgnore)
+	for _, pattern := range p.Ignore {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+		ignore = append(ignore, pattern)
+	}
+	p.Ignore = ignore
+
+	scanMu.Lock()
+	scanPolicy = p
+	scanMu.Unlock()
+	return nil
+}
+
+// fingerprint identifies the policy in the repository index, which is only
+// valid for the policy it was built with
+func (p ScanPolicy) fingerprint() string {
+	return fmt.Sprintf("depth=%d nested=%t ignore=%s", p.MaxDepth, p.Nested, strings.Join(p.Ignore, ","))
+}
+
+// currentScanPolicy returns the installed scan policy
+func currentScanPolicy() ScanPolicy {
+	scanMu.RLock()
+	defer scanMu.RUnlock()
+	return scanPolicy
+}
+
+// ignored reports whether the scan skips the directory at rel, a
+// slash-separated path below the base path
+func (p ScanPolicy) ignored(rel string) bool {
+	name := path.Base(rel)
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, pattern := range p.Ignore {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// scanDir is a directory waiting in the Scan queue. inRepo is set below a
+// repository, whose plain directories are not organizational; rules are the
+// ignore files found on the way to dir.
+type scanDir struct {
+	dir, rel string
+	depth    int
+	inRepo   bool
+	rules    ignoreRules
+}
+
+// dirQueue hands the directories of a scan to a fixed set of workers
+type dirQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	dirs    []scanDir
+	pending int // Directories queued or being visited
+}
+
+func newDirQueue() *dirQueue {
+	q := &dirQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a directory for a worker
+func (q *dirQueue) push(d scanDir) {
+	q.mu.Lock()
+	q.dirs = append(q.dirs, d)
+	q.pending++
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// pop waits for a queued directory. It returns false once every directory
+// has been visited, as nothing can queue more then.
+func (q *dirQueue) pop() (scanDir, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.dirs) == 0 && q.pending > 0 {
+		q.cond.Wait()
+	}
+	if len(q.dirs) == 0 {
+		return scanDir{}, false
+	}
+	d := q.dirs[0]
+	q.dirs[0] = scanDir{}
+	q.dirs = q.dirs[1:]
+	return d, true
+}
+
+// done marks a popped directory as visited, after its subdirectories are queued
+func (q *dirQueue) done() {
+	q.mu.Lock()
+	q.pending--
+	if q.pending == 0 {
+		q.cond.Broadcast()
+	}
+	q.mu.Unlock()
+}
+
+// Scan walks the base path once and calls found for every repository and
+// organizational directory as soon as it is seen, so callers can show
+// results before the walk ends. Directories are read concurrently and found
+// is never called concurrently, but the order of the calls is not defined.
+// The walk does not descend into repositories, hidden directories or
+// directories the scan policy or a .get-repoignore file (see
+// IgnoreFileName) ignores; the submodules a repository declares
+// are reported after it, and with ScanPolicy.Nested its working tree is
+// searched too. Directories that cannot be read are skipped; only an
+// unreadable base path is an error. A scan that completes rebuilds and saves
+// the index (see OpenIndex). With roots, all of them are scanned at once.
+func (m *Manager) Scan(ctx context.Context, found func(Repository)) error {
+	if len(m.roots) > 0 {
+		return m.scanRoots(ctx, found)
+	}
+	defer debug.LogFunction("Manager.Scan")()
+	debug.Log("Scanning base path: %s", m.basePath)
+
+	if _, err := os.Stat(m.basePath); os.IsNotExist(err) {
+		debug.Log("Base path does not exist: %s", m.basePath)
+		return fmt.Errorf("base path does not exist: %s", m.basePath)
+	}
+	if _, err := os.ReadDir(m.basePath); err != nil {
+		return fmt.Errorf("failed to read base path: %w", err)
+	}
+
+	policy := currentScanPolicy()
+
+	var (
+		queue   = newDirQueue()
+		foundMu sync.Mutex
+		count   int
+
+		// What the index needs: the repositories and the searched directories
+		indexed []Repository
+		dirs    = make(map[string]indexedDir)
+	)
+	report := func(r Repository) {
+		foundMu.Lock()
+		defer foundMu.Unlock()
+		count++
+		if m.index != nil && r.IsGitDir {
+			indexed = append(indexed, r)
+		}
+		found(r)
+	}
+
+	// skipped reports whether the directory at rel is left out
+	skipped := func(rel string, rules ignoreRules) bool {
+		slashed := filepath.ToSlash(rel)
+		if policy.ignored(slashed) || rules.ignored(slashed) {
+			debug.Log("Skipping ignored directory: %s", rel)
+			return true
+		}
+		return false
+	}
+
+	// reportSubmodules reports the initialized submodules of the checkout at
+	// dir and, recursively, theirs, unless a directory on the way is ignored
+	var reportSubmodules func(dir, rel string, rules ignoreRules)
+	reportSubmodules = func(dir, rel string, rules ignoreRules) {
+	submodules:
+		for _, sub := range submodulePaths(dir) {
+			if !filepath.IsLocal(sub) {
+				continue
+			}
+			subRel := rel
+			for _, part := range strings.Split(sub, string(filepath.Separator)) {
+				subRel = filepath.Join(subRel, part)
+				if skipped(subRel, rules) {
+					continue submodules
+				}
+			}
+			if _, ok := DetectKind(filepath.Join(dir, sub)); ok {
+				report(Repository{Name: subRel, Path: filepath.Join(dir, sub), IsGitDir: true, Kind: KindSubmodule})
+				reportSubmodules(filepath.Join(dir, sub), subRel, rules)
+			}
+		}
+	}
+
+	// visit reads one directory and queues its subdirectories
+	visit := func(d scanDir) {
+		if ctx.Err() != nil {
+			return
+		}
+		dir, rel, depth, inRepo, rules := d.dir, d.rel, d.depth, d.inRepo, d.rules
+
+		var modTime time.Time
+		if m.index != nil {
+			// Before reading, so a change during the scan invalidates the index
+			if info, err := os.Stat(dir); err == nil {
+				modTime = info.ModTime()
+			}
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			debug.LogError(err, fmt.Sprintf("reading %s", dir))
+			return
+		}
+
+		listed := false
+		if rel != "" {
+			if kind, ok := kindOf(dir, entries); ok {
+				debug.Log("Found git repository: %s (%s)", rel, kind)
+				report(Repository{Name: rel, Path: dir, IsGitDir: true, Kind: kind})
+				if kind == KindBare {
+					return
+				}
+				if !policy.Nested {
+					reportSubmodules(dir, rel, rules)
+					return
+				}
+				// Submodules are found by the search like nested repositories
+				inRepo = true
+			} else if depth <= organizationDepth && !inRepo {
+				report(Repository{Name: rel, Path: dir, IsGitDir: false})
+				listed = true
+			}
+		}
+
+		if policy.MaxDepth > 0 && depth >= policy.MaxDepth {
+			return
+		}
+		if m.index != nil {
+			foundMu.Lock()
+			dirs[filepath.ToSlash(rel)] = indexedDir{ModTime: modTime, Listed: listed}
+			foundMu.Unlock()
+		}
+		if slices.ContainsFunc(entries, func(e os.DirEntry) bool { return e.Name() == IgnoreFileName && !e.IsDir() }) {
+			if f, ok := loadIgnoreFile(dir, rel); ok {
+				rules = rules.with(f)
+			}
+			if m.index != nil {
+				// Editing the file does not change the directory, so the
+				// index watches the file itself
+				if info, err := os.Stat(filepath.Join(dir, IgnoreFileName)); err == nil {
+					foundMu.Lock()
+					dirs[path.Join(filepath.ToSlash(rel), IgnoreFileName)] = indexedDir{ModTime: info.ModTime()}
+					foundMu.Unlock()
+				}
+			}
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			childRel := filepath.Join(rel, entry.Name())
+			if skipped(childRel, rules) {
+				continue
+			}
+			queue.push(scanDir{dir: filepath.Join(dir, entry.Name()), rel: childRel, depth: depth + 1, inRepo: inRepo, rules: rules})
+		}
+	}
+
+	// Directory reads are mostly waiting on the disk, so there are more
+	// workers than CPUs
+	var wg sync.WaitGroup
+	queue.push(scanDir{dir: m.basePath})
+	for range 4 * runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				d, ok := queue.pop()
+				if !ok {
+					return
+				}
+				visit(d)
+				queue.done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	debug.Log("Repository scan complete, total found: %d", count)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("scan interrupted: %w", err)
+	}
+
+	if m.index != nil {
+		m.index.rebuild(policy.fingerprint(), indexed, dirs)
+		if err := m.index.Save(); err != nil {
+			// The index only saves time; the next scan tries again
+			debug.LogError(err, "saving repository index")
+		}
+	}
+	return nil
+}
+
+// scanRoots is Scan for every root at once. Each root is reported as a
+// directory, and what is found in it is named after it. Roots that do not
+// exist, such as an unmounted volume, are skipped unless none exists.
+func (m *Manager) scanRoots(ctx context.Context, found func(Repository)) error {
+	var (
+		wg      sync.WaitGroup
+		foundMu sync.Mutex
+		errs    = make([]error, len(m.roots))
+	)
+	for i, r := range m.roots {
+		if !r.exists() {
+			errs[i] = fmt.Errorf("root %s does not exist: %s", r.name, r.basePath)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report := func(rp Repository) {
+				foundMu.Lock()
+				defer foundMu.Unlock()
+				found(rp)
+			}
+			report(Repository{Name: r.name, Path: r.basePath})
+			errs[i] = r.Scan(ctx, func(rp Repository) {
+				rp.Name = filepath.Join(r.name, rp.Name)
+				report(rp)
+			})
+		}()
+	}
+	wg.Wait()
+	return m.rootsError(errs)
+}
+
+// listRoots runs list for every root and merges the results the way List
+// orders them, with each root listed as a directory. Roots that do not
+// exist are skipped unless none exists.
+func (m *Manager) listRoots(list func(*Manager) ([]Repository, error)) ([]Repository, error) {
+	results := make([][]Repository, len(m.roots))
+	errs := make([]error, len(m.roots))
+	var wg sync.WaitGroup
+	for i, r := range m.roots {
+		if !r.exists() {
+			errs[i] = fmt.Errorf("root %s does not exist: %s", r.name, r.basePath)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = list(r.Manager)
+		}()
+	}
+	wg.Wait()
+	if err := m.rootsError(errs); err != nil {
+		return nil, err
+	}
+
+	var repos, dirs []Repository
+	for i, r := range m.roots {
+		if errs[i] != nil {
+			continue
+		}
+		dirs = append(dirs, Repository{Name: r.name, Path: r.basePath})
+		for _, rp := range results[i] {
+			rp.Name = filepath.Join(r.name, rp.Name)
+			if rp.IsGitDir {
+				repos = append(repos, rp)
+			} else {
+				dirs = append(dirs, rp)
+			}
+		}
+	}
+	SortRepositories(repos)
+	SortRepositories(dirs)
+	return append(repos, dirs...), nil
+}
+
+// exists reports whether the base path exists
+func (m *Manager) exists() bool {
+	_, err := os.Stat(m.basePath)
+	return !os.IsNotExist(err)
+}
+
+// rootsError combines the errors of the roots, errs in the order of
+// m.roots. Missing roots are only an error when every root is missing.
+func (m *Manager) rootsError(errs []error) error {
+	missing := 0
+	var failed []error
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case !m.roots[i].exists():
+			debug.Log("Skipping missing root %s: %s", m.roots[i].name, m.roots[i].basePath)
+			missing++
+		default:
+			failed = append(failed, fmt.Errorf("root %s: %w", m.roots[i].name, err))
+		}
+	}
+	if missing == len(m.roots) {
+		return fmt.Errorf("none of the roots exist: %w", errors.Join(errs...))
+	}
+	return errors.Join(failed...)
+}
+
+// List returns all repositories found under the base path, sorted by name,
+// followed by the organizational directories, also sorted. With an index
+// (see OpenIndex) the disk is only scanned when a directory the index was
+// built from has changed since.
+func (m *Manager) List() ([]Repository, error) {
+	if len(m.roots) > 0 {
+		return m.listRoots((*Manager).List)
+	}
+	if m.index != nil {
+		if repos, ok := m.index.repositories(currentScanPolicy().fingerprint(), true); ok {
+			return repos, nil
+		}
+	}
+	return m.Rescan()
+}
+
+// ListCached is List answered from the index as it is, without checking it
+// against the disk, so it may miss recent changes made without get-repo.
+// Without a usable index it scans like List.
+func (m *Manager) ListCached() ([]Repository, error) {
+	if len(m.roots) > 0 {
+		return m.listRoots((*Manager).ListCached)
+	}
+	if repos, ok := m.Cached(); ok {
+		return repos, nil
+	}
+	return m.Rescan()
+}
+
+// Cached returns what ListCached would from the index, or false if there
+// is no usable index
+func (m *Manager) Cached() ([]Repository, bool) {
+	if len(m.roots) > 0 {
+		repos, err := m.listRoots(func(rm *Manager) ([]Repository, error) {
+			if cached, ok := rm.Cached(); ok {
+				return cached, nil
+			}
+			return nil, fmt.Errorf("no usable index")
+		})
+		return repos, err == nil
+	}
+	if m.index == nil {
+		return nil, false
+	}
+	return m.index.repositories(currentScanPolicy().fingerprint(), false)
+}
+
+// Rescan is List ignoring the index, which the scan then rebuilds
+func (m *Manager) Rescan() ([]Repository, error) {
+	if len(m.roots) > 0 {
+		return m.listRoots((*Manager).Rescan)
+	}
+	var repos, dirs []Repository
+	err := m.Scan(context.Background(), func(r Repository) {
+		if r.IsGitDir {
+			repos = append(repos, r)
+		} else {
+			dirs = append(dirs, r)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	SortRepositories(repos)
+	SortRepositories(dirs)
+	return append(repos, dirs...), nil
+}
+
+// SortRepositories orders repositories by name the way a directory walk
+// visits them, comparing one path element at a time
+func SortRepositories(repos []Repository) {
+	slices.SortFunc(repos, func(a, b Repository) int {
+		return slices.Compare(strings.Split(a.Name, string(filepath.Separator)), strings.Split(b.Name, string(filepath.Separator)))
+	})
+}
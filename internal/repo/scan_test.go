@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// syntheticTree builds hosts/owners/repos checkouts under base, each with a
+// few source directories, plus a project per owner that is not a repository
+// and holds a node_modules full of packages that look like checkouts. It
+// returns the number of repositories a scan should find.
+func syntheticTree(tb testing.TB, base string, hosts, owners, repos int) int {
+	tb.Helper()
+	mkdir := func(parts ...string) {
+		if err := os.MkdirAll(filepath.Join(parts...), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	for h := 0; h < hosts; h++ {
+		for o := 0; o < owners; o++ {
+			owner := filepath.Join(base, fmt.Sprintf("host%d.example", h), fmt.Sprintf("owner%d", o))
+			for r := 0; r < repos; r++ {
+				repo := filepath.Join(owner, fmt.Sprintf("repo%d", r))
+				mkdir(repo, ".git", "objects")
+				mkdir(repo, "src", "internal")
+				mkdir(repo, "docs")
+			}
+			for p := 0; p < 20; p++ {
+				mkdir(owner, "scratch", "node_modules", fmt.Sprintf("pkg%d", p), ".git")
+				mkdir(owner, "scratch", "node_modules", fmt.Sprintf("pkg%d", p), "lib")
+			}
+		}
+	}
+	return hosts * owners * repos
+}
+
+func TestScanSyntheticTree(t *testing.T) {
+	base := t.TempDir()
+	want := syntheticTree(t, base, 2, 3, 4)
+
+	repos, err := NewManager(base).List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var found, dirs int
+	for _, r := range repos {
+		if r.IsGitDir {
+			found++
+		} else {
+			dirs++
+		}
+	}
+	if found != want {
+		t.Errorf("found %d repositories, want %d", found, want)
+	}
+	// The hosts and the owners
+	if want := 2 + 2*3; dirs != want {
+		t.Errorf("listed %d directories, want %d", dirs, want)
+	}
+}
+
+func TestScanCancelled(t *testing.T) {
+	base := t.TempDir()
+	syntheticTree(t, base, 1, 2, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewManager(base).Scan(ctx, func(Repository) {}); err == nil {
+		t.Error("Scan with a cancelled context succeeded")
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	base := b.TempDir()
+	syntheticTree(b, base, 3, 20, 25)
+	m := NewManager(base)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.Scan(context.Background(), func(Repository) {}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkList(b *testing.B) {
+	base := b.TempDir()
+	syntheticTree(b, base, 3, 20, 25)
+	m := NewManager(base)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.List(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
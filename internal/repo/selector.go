@@ -0,0 +1,329 @@
+package repo
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Selector picks repositories by their name below the base path, e.g.
+// "github.com/myorg/tool". Each pattern is a name, which also selects the
+// repositories below it, a glob such as "github.com/myorg/*" (or "*-archive",
+// which without a slash matches any part of the name), or a regular
+// expression prefixed with "re:". "@name" selects a tag or configured group
+// (see SetGroups). Patterns starting with "!" exclude what they match.
+// Hosts and owners are matched against the first and the middle parts of the
+// name, which is where the default layout puts them. With roots installed
+// (see SetRoots) names start with their root, which patterns may leave out.
+type Selector struct {
+	Patterns []string // Names, globs, "re:" expressions and "@" groups; "!" negates
+	Hosts    []string // Host globs, e.g. "github.com" or "*.corp.example"
+	Owners   []string // Owner globs, e.g. "myorg"; "group" also matches "group/subgroup"
+	Roots    []string // Root names, e.g. "work"
+	All      bool     // Select every repository (that the other conditions allow)
+}
+
+// IsEmpty reports whether the selector was not given any condition. Commands
+// treat this differently from --all, e.g. update launches the TUI.
+func (s Selector) IsEmpty() bool {
+	return len(s.Patterns) == 0 && len(s.Hosts) == 0 && len(s.Owners) == 0 && len(s.Roots) == 0 && !s.All
+}
+
+// IsExplicit reports whether the selector names what it picks, with an
+// include pattern or --all. Host, owner and root filters and excludes only
+// narrow down every repository, which destructive commands must not assume.
+func (s Selector) IsExplicit() bool {
+	if s.All {
+		return true
+	}
+	for _, pattern := range s.Patterns {
+		if !strings.HasPrefix(pattern, "!") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSelectorFlag parses a selector option at the start of args into s and
+// returns how many arguments it used: 0 if args[0] is not a selector option,
+// 2 for "--host github.com" and 1 for "--host=github.com" or "--all".
+func ParseSelectorFlag(args []string, s *Selector) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+
+	name, value, inline := strings.Cut(args[0], "=")
+	used := 1
+	switch name {
+	case "--all":
+		if inline {
+			return 0, fmt.Errorf("--all does not take a value")
+		}
+		s.All = true
+		return 1, nil
+	case "--host", "--owner", "--root", "--not":
+		if !inline {
+			if len(args) < 2 {
+				return 0, fmt.Errorf("%s requires a pattern", name)
+			}
+			value = args[1]
+			used = 2
+		}
+		if value == "" {
+			return 0, fmt.Errorf("%s requires a pattern", name)
+		}
+	default:
+		return 0, nil
+	}
+
+	switch name {
+	case "--host":
+		s.Hosts = append(s.Hosts, value)
+	case "--owner":
+		s.Owners = append(s.Owners, value)
+	case "--root":
+		s.Roots = append(s.Roots, value)
+	case "--not":
+		s.Patterns = append(s.Patterns, "!"+value)
+	}
+	return used, nil
+}
+
+// ParseSelector parses patterns mixed with selector options, as typed into
+// the TUI: "github.com/myorg/* !*-archive --host gitlab.com"
+func ParseSelector(spec string) (Selector, error) {
+	var s Selector
+	fields := strings.Fields(spec)
+	for i := 0; i < len(fields); i++ {
+		used, err := ParseSelectorFlag(fields[i:], &s)
+		if err != nil {
+			return s, err
+		}
+		if used == 0 {
+			if strings.HasPrefix(fields[i], "--") {
+				return s, fmt.Errorf("unknown option %s", fields[i])
+			}
+			s.Patterns = append(s.Patterns, fields[i])
+			continue
+		}
+		i += used - 1
+	}
+	return s, nil
+}
+
+// nameMatcher reports whether a slash-separated repository name matches
+type nameMatcher func(name string) bool
+
+// compilePattern turns a name, glob, "re:" or "@group" pattern into a matcher
+func compilePattern(pattern string) (nameMatcher, error) {
+	if group, ok := strings.CutPrefix(pattern, "@"); ok {
+		return groupMatcher(group)
+	}
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+		}
+		return re.MatchString, nil
+	}
+
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty repository pattern")
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		// As in .gitignore, a glob without a slash matches any part of the name
+		if !strings.Contains(pattern, "/") {
+			return func(name string) bool {
+				for _, part := range strings.Split(name, "/") {
+					if matched, _ := path.Match(pattern, part); matched {
+						return true
+					}
+				}
+				return false
+			}, nil
+		}
+		// Like submodule excludes, a glob also selects what is below a match
+		return func(name string) bool {
+			for ; name != "." && name != "/" && name != ""; name = path.Dir(name) {
+				if matched, _ := path.Match(pattern, name); matched {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	return func(name string) bool {
+		return name == pattern || strings.HasPrefix(name, pattern+"/")
+	}, nil
+}
+
+// Select returns the names that the selector picks, in the order of the
+// patterns that selected them, then in the order given. Each name appears at
+// most once. A pattern that selects nothing is an error, so typos are not
+// silently ignored.
+func (s Selector) Select(names []string) ([]string, error) {
+	var includes, excludes []nameMatcher
+	var includePatterns []string
+	for _, pattern := range s.Patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		match, err := compilePattern(strings.TrimPrefix(pattern, "!"))
+		if err != nil {
+			return nil, err
+		}
+		if negated {
+			excludes = append(excludes, match)
+		} else {
+			includes = append(includes, match)
+			includePatterns = append(includePatterns, pattern)
+		}
+	}
+	for _, glob := range append(append([]string{}, s.Hosts...), s.Owners...) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", glob, err)
+		}
+	}
+	if err := CheckRootNames(s.Roots); err != nil {
+		return nil, err
+	}
+
+	// Excludes and host/owner/root conditions apply to every include
+	allowed := func(name string) bool {
+		if len(s.Roots) > 0 {
+			rootName, _ := splitRoot(name)
+			if !slices.Contains(s.Roots, rootName) {
+				return false
+			}
+		}
+		if len(s.Hosts) > 0 && !matchAny(s.Hosts, hostPart(name)) {
+			return false
+		}
+		if len(s.Owners) > 0 && !matchOwner(s.Owners, ownerPart(name)) {
+			return false
+		}
+		for _, exclude := range excludes {
+			if matchInRoot(exclude, name) {
+				return false
+			}
+		}
+		return true
+	}
+
+	slashNames := make([]string, len(names))
+	for i, name := range names {
+		slashNames[i] = filepath.ToSlash(name)
+	}
+
+	var selected []string
+	seen := make(map[string]bool)
+	add := func(i int) {
+		if !seen[names[i]] {
+			seen[names[i]] = true
+			selected = append(selected, names[i])
+		}
+	}
+
+	if len(includes) == 0 {
+		for i, name := range slashNames {
+			if allowed(name) {
+				add(i)
+			}
+		}
+		filtered := len(s.Hosts) > 0 || len(s.Owners) > 0 || len(s.Roots) > 0 || len(excludes) > 0
+		if len(selected) == 0 && filtered {
+			return nil, fmt.Errorf("no repositories match")
+		}
+		return selected, nil
+	}
+
+	for p, include := range includes {
+		found := false
+		for i, name := range slashNames {
+			if matchInRoot(include, name) && allowed(name) {
+				found = true
+				add(i)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no repositories match %q", includePatterns[p])
+		}
+	}
+	return selected, nil
+}
+
+// matchInRoot reports whether match accepts the name, with or without the
+// root it starts with
+func matchInRoot(match nameMatcher, name string) bool {
+	if match(name) {
+		return true
+	}
+	rootName, rel := splitRoot(name)
+	return rootName != "" && rel != "" && match(rel)
+}
+
+// hostPart returns the first part of a repository name after its root
+func hostPart(name string) string {
+	_, name = splitRoot(name)
+	host, _, _ := strings.Cut(name, "/")
+	return host
+}
+
+// ownerPart returns the parts of a repository name between the host and the
+// repository itself, e.g. "group/subgroup", or "" if there are none
+func ownerPart(name string) string {
+	_, name = splitRoot(name)
+	_, rest, found := strings.Cut(name, "/")
+	if !found {
+		return ""
+	}
+	owner := path.Dir(rest)
+	if owner == "." {
+		return ""
+	}
+	return owner
+}
+
+// matchAny reports whether any of the globs matches value
+func matchAny(globs []string, value string) bool {
+	for _, glob := range globs {
+		if matched, _ := path.Match(glob, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOwner reports whether any of the globs matches the owner or one of its
+// leading parts, so "group" matches "group/subgroup"
+func matchOwner(globs []string, owner string) bool {
+	for ; owner != "." && owner != ""; owner = path.Dir(owner) {
+		if matchAny(globs, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the names of the git repositories the selector picks
+func (m *Manager) Select(s Selector) ([]string, error) {
+	repos, err := m.List()
+	if err != nil {
+		return nil, fmt.Errorf("error scanning repositories: %w", err)
+	}
+
+	var names []string
+	for _, rp := range repos {
+		if rp.IsGitDir {
+			names = append(names, rp.Name)
+		}
+	}
+	return s.Select(names)
+}
@@ -0,0 +1,190 @@
+package repo
+
+import (
+	"slices"
+	"testing"
+)
+
+var selectorNames = []string{
+	"github.com/me/tool",
+	"github.com/me/tool-archive",
+	"github.com/myorg/api",
+	"github.com/myorg/web",
+	"gitlab.com/group/sub/svc",
+	"gitlab.com/group/lib",
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Selector
+	}{
+		{spec: "", want: Selector{}},
+		{spec: "github.com/me", want: Selector{Patterns: []string{"github.com/me"}}},
+		{
+			spec: "github.com/myorg/* !*-archive --host gitlab.com",
+			want: Selector{Patterns: []string{"github.com/myorg/*", "!*-archive"}, Hosts: []string{"gitlab.com"}},
+		},
+		{
+			spec: "--owner=group --not lib --all",
+			want: Selector{Patterns: []string{"!lib"}, Owners: []string{"group"}, All: true},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSelector(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSelector(%q): %v", tt.spec, err)
+			continue
+		}
+		if !slices.Equal(got.Patterns, tt.want.Patterns) || !slices.Equal(got.Hosts, tt.want.Hosts) ||
+			!slices.Equal(got.Owners, tt.want.Owners) || got.All != tt.want.All {
+			t.Errorf("ParseSelector(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"--host", "--bogus", "--all=yes", "--owner="} {
+		if _, err := ParseSelector(spec); err == nil {
+			t.Errorf("ParseSelector(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestSelectorSelect(t *testing.T) {
+	tests := []struct {
+		name string
+		sel  Selector
+		want []string
+	}{
+		{
+			name: "folder",
+			sel:  Selector{Patterns: []string{"github.com/me"}},
+			want: []string{"github.com/me/tool", "github.com/me/tool-archive"},
+		},
+		{
+			name: "exact name is not a prefix",
+			sel:  Selector{Patterns: []string{"github.com/me/tool"}},
+			want: []string{"github.com/me/tool"},
+		},
+		{
+			name: "glob with slash",
+			sel:  Selector{Patterns: []string{"github.com/*/api"}},
+			want: []string{"github.com/myorg/api"},
+		},
+		{
+			name: "glob without slash matches any part",
+			sel:  Selector{Patterns: []string{"*-archive"}},
+			want: []string{"github.com/me/tool-archive"},
+		},
+		{
+			name: "regular expression",
+			sel:  Selector{Patterns: []string{`re:^gitlab\.com/.*svc$`}},
+			want: []string{"gitlab.com/group/sub/svc"},
+		},
+		{
+			name: "exclude",
+			sel:  Selector{Patterns: []string{"github.com", "!*-archive", "!github.com/myorg"}},
+			want: []string{"github.com/me/tool"},
+		},
+		{
+			name: "order of patterns, each once",
+			sel:  Selector{Patterns: []string{"github.com/myorg/web", "github.com/myorg"}},
+			want: []string{"github.com/myorg/web", "github.com/myorg/api"},
+		},
+		{
+			name: "host",
+			sel:  Selector{Hosts: []string{"gitlab.*"}},
+			want: []string{"gitlab.com/group/sub/svc", "gitlab.com/group/lib"},
+		},
+		{
+			name: "owner matches subgroups",
+			sel:  Selector{Owners: []string{"group"}},
+			want: []string{"gitlab.com/group/sub/svc", "gitlab.com/group/lib"},
+		},
+		{
+			name: "owner and exclude",
+			sel:  Selector{Owners: []string{"me"}, Patterns: []string{"!*tool"}},
+			want: []string{"github.com/me/tool-archive"},
+		},
+		{
+			name: "all",
+			sel:  Selector{All: true},
+			want: selectorNames,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sel.Select(selectorNames)
+			if err != nil {
+				t.Fatalf("Select: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Select = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorSelectErrors(t *testing.T) {
+	for _, sel := range []Selector{
+		{Patterns: []string{"github.com/nobody"}},
+		{Patterns: []string{"github.com/me", "missing"}},
+		{Patterns: []string{"re:("}},
+		{Patterns: []string{"github.com/[me"}},
+		{Hosts: []string{"bitbucket.org"}},
+		{Patterns: []string{"!github.com", "!gitlab.com"}},
+		{Roots: []string{"work"}},
+	} {
+		if got, err := sel.Select(selectorNames); err == nil {
+			t.Errorf("%+v selected %q, want an error", sel, got)
+		}
+	}
+}
+
+func TestSelectorIsExplicit(t *testing.T) {
+	tests := []struct {
+		sel  Selector
+		want bool
+	}{
+		{Selector{}, false},
+		{Selector{Patterns: []string{"!foo"}}, false},
+		{Selector{Hosts: []string{"github.com"}}, false},
+		{Selector{Owners: []string{"me"}, Patterns: []string{"!tool"}}, false},
+		{Selector{Patterns: []string{"github.com/me"}}, true},
+		{Selector{Patterns: []string{"!foo"}, All: true}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.sel.IsExplicit(); got != tt.want {
+			t.Errorf("%+v.IsExplicit() = %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+}
+
+func TestSelectorGroups(t *testing.T) {
+	store, err := LoadTagStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Tag("pay", []string{"github.com/myorg/api"})
+	previous := Tags()
+	SetTagStore(store)
+	if err := SetGroups(map[string][]string{"mine": {"github.com/me", "!*-archive"}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetGroups(nil)
+		SetTagStore(previous)
+	})
+
+	got, err := Selector{Patterns: []string{"@mine", "@pay"}}.Select(selectorNames)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if want := []string{"github.com/me/tool", "github.com/myorg/api"}; !slices.Equal(got, want) {
+		t.Errorf("Select = %q, want %q", got, want)
+	}
+	if _, err := (Selector{Patterns: []string{"@nope"}}).Select(selectorNames); err == nil {
+		t.Errorf("unknown group selected repositories, want an error")
+	}
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RepoStatus summarizes the state of a checkout, from local data only
+type RepoStatus struct {
+	Kind       Kind      `json:"kind"`               // Normal checkout, worktree, bare repository or submodule
+	Branch     string    `json:"branch,omitempty"`   // Checked out branch, empty when detached
+	Detached   bool      `json:"detached"`           // HEAD is not on a branch
+	Commit     string    `json:"commit,omitempty"`   // Hash of HEAD, empty for an empty repository
+	Upstream   string    `json:"upstream,omitempty"` // e.g. "origin/main"
+	Ahead      int       `json:"ahead"`              // Commits not pushed to the upstream
+	Behind     int       `json:"behind"`             // Upstream commits not pulled, as of the last fetch
+	Changed    int       `json:"changed"`            // Modified, staged or conflicted paths
+	Untracked  int       `json:"untracked"`          // Untracked paths
+	Stashes    int       `json:"stashes"`            // Entries in the stash
+	LastCommit time.Time `json:"last_commit"`        // Commit time of HEAD, zero for an empty repository
+}
+
+// Dirty reports whether the working tree has changes or untracked files
+func (s RepoStatus) Dirty() bool {
+	return s.Changed > 0 || s.Untracked > 0
+}
+
+// RepoStatus inspects a checkout: branch, upstream distance, working tree
+// changes, stashes and the age of the last commit. It does not contact any
+// remote; run Fetch first for current behind counts.
+func (g *Git) RepoStatus(ctx context.Context, repoPath string) (RepoStatus, error) {
+	ctx, cancel := withTimeout(ctx, g.timeouts.Status)
+	defer cancel()
+
+	var status RepoStatus
+	status.Kind, _ = DetectKind(repoPath)
+	if status.Kind == KindBare {
+		// No working tree to inspect, and no upstream to compare with
+		if branch, err := g.runCommand(g.command(ctx, "-C", repoPath, "symbolic-ref", "--quiet", "--short", "HEAD")); err == nil {
+			status.Branch = strings.TrimSpace(branch)
+		} else {
+			status.Detached = true
+		}
+		if commit, err := g.runCommand(g.command(ctx, "-C", repoPath, "rev-parse", "--quiet", "--verify", "HEAD")); err == nil {
+			status.Commit = strings.TrimSpace(commit)
+		}
+	} else {
+		output, err := g.runCommand(g.command(ctx, "-C", repoPath, "status", "--porcelain=v2", "--branch"))
+		if err != nil {
+			return status, contextError(ctx, "status", g.timeouts.Status, err)
+		}
+		if err := parsePorcelainV2(output, &status); err != nil {
+			return status, err
+		}
+	}
+
+	// refs/stash is missing when nothing is stashed
+	if stashes, err := g.runCommand(g.command(ctx, "-C", repoPath, "rev-list", "--walk-reflogs", "--count", "refs/stash")); err == nil {
+		status.Stashes, _ = strconv.Atoi(strings.TrimSpace(stashes))
+	}
+
+	// Empty repositories have no commit yet
+	if committed, err := g.runCommand(g.command(ctx, "-C", repoPath, "log", "-1", "--format=%ct")); err == nil {
+		if seconds, err := strconv.ParseInt(strings.TrimSpace(committed), 10, 64); err == nil {
+			status.LastCommit = time.Unix(seconds, 0)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return status, contextError(ctx, "status", g.timeouts.Status, ctx.Err())
+	}
+	return status, nil
+}
+
+// parsePorcelainV2 reads the branch headers and entries of
+// "git status --porcelain=v2 --branch"
+func parsePorcelainV2(output string, status *RepoStatus) error {
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			head := strings.TrimPrefix(line, "# branch.head ")
+			if head == "(detached)" {
+				status.Detached = true
+			} else {
+				status.Branch = head
+			}
+		case strings.HasPrefix(line, "# branch.oid "):
+			if oid := strings.TrimPrefix(line, "# branch.oid "); oid != "(initial)" {
+				status.Commit = oid
+			}
+		case strings.HasPrefix(line, "# branch.upstream "):
+			status.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			// "# branch.ab +1 -3"
+			if _, err := fmt.Sscanf(line, "# branch.ab +%d -%d", &status.Ahead, &status.Behind); err != nil {
+				return fmt.Errorf("unexpected status line %q", line)
+			}
+		case strings.HasPrefix(line, "1 "), strings.HasPrefix(line, "2 "), strings.HasPrefix(line, "u "):
+			status.Changed++
+		case strings.HasPrefix(line, "? "):
+			status.Untracked++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repo
+
+import "testing"
+
+func TestParsePorcelainV2(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   RepoStatus
+	}{
+		{
+			name:   "clean branch with upstream",
+			output: "# branch.oid 0123abcd\n# branch.head main\n# branch.upstream origin/main\n# branch.ab +2 -5\n",
+			want:   RepoStatus{Branch: "main", Commit: "0123abcd", Upstream: "origin/main", Ahead: 2, Behind: 5},
+		},
+		{
+			name: "changes and untracked files",
+			output: "# branch.oid 0123abcd\n# branch.head feature/x\n" +
+				"1 .M N... 100644 100644 100644 aaa bbb file.go\n" +
+				"2 R. N... 100644 100644 100644 aaa bbb R100 new.go\told.go\n" +
+				"u UU N... 100644 100644 100644 100644 aaa bbb ccc conflict.go\n" +
+				"? notes.txt\n? tmp/\n" +
+				"! ignored.log\n",
+			want: RepoStatus{Branch: "feature/x", Commit: "0123abcd", Changed: 3, Untracked: 2},
+		},
+		{
+			name:   "detached",
+			output: "# branch.oid 0123abcd\n# branch.head (detached)\n",
+			want:   RepoStatus{Detached: true, Commit: "0123abcd"},
+		},
+		{
+			name:   "empty repository",
+			output: "# branch.oid (initial)\n# branch.head main\n? README.md\n",
+			want:   RepoStatus{Branch: "main", Untracked: 1},
+		},
+		{
+			name:   "no output",
+			output: "",
+			want:   RepoStatus{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RepoStatus
+			if err := parsePorcelainV2(tt.output, &got); err != nil {
+				t.Fatalf("parsePorcelainV2: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parsePorcelainV2 = %+v, want %+v", got, tt.want)
+			}
+			if got.Dirty() != (tt.want.Changed > 0 || tt.want.Untracked > 0) {
+				t.Errorf("Dirty() = %v", got.Dirty())
+			}
+		})
+	}
+
+	var status RepoStatus
+	if err := parsePorcelainV2("# branch.ab one -two\n", &status); err == nil {
+		t.Error("parsePorcelainV2 accepted a malformed branch.ab line")
+	}
+}
@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SubmodulePolicy controls which repositories get their submodules
+// initialized on clone and updated after pulls
+type SubmodulePolicy struct {
+	Disabled bool     // Leave submodules alone everywhere
+	Exclude  []string // Repository globs, matched against the path below the base path or any of its parents
+}
+
+var (
+	submoduleMu     sync.RWMutex
+	submodulePolicy SubmodulePolicy
+)
+
+// SetSubmodulePolicy installs the policy used by clones and pulls
+func SetSubmodulePolicy(p SubmodulePolicy) error {
+	exclude := make([]string, 0, len(p.Exclude))
+	for _, pattern := range p.Exclude {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid submodule exclude pattern %q: %w", pattern, err)
+		}
+		exclude = append(exclude, pattern)
+	}
+	p.Exclude = exclude
+
+	submoduleMu.Lock()
+	submodulePolicy = p
+	submoduleMu.Unlock()
+	return nil
+}
+
+// submodulesEnabled reports whether the policy allows touching the
+// submodules of the repository at repoPath
+func (g *Git) submodulesEnabled(repoPath string) bool {
+	submoduleMu.RLock()
+	defer submoduleMu.RUnlock()
+
+	if submodulePolicy.Disabled {
+		return false
+	}
+	name := filepath.ToSlash(repoPath)
+	if rel, ok := relativeToRoot(repoPath); ok {
+		name = rel
+	} else if rel, err := filepath.Rel(g.workDir, repoPath); err == nil && !strings.HasPrefix(rel, "..") {
+		name = filepath.ToSlash(rel)
+	}
+	for ; name != "." && name != "/" && name != ""; name = path.Dir(name) {
+		for _, pattern := range submodulePolicy.Exclude {
+			if matched, _ := path.Match(pattern, name); matched {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasSubmodules reports whether the checkout at repoPath declares submodules
+func hasSubmodules(repoPath string) bool {
+	_, err := os.Stat(filepath.Join(repoPath, ".gitmodules"))
+	return err == nil
+}
+
+// updateSubmodules initializes and updates the submodules of a checkout,
+// recursively, unless it has none or the policy excludes it. Depth and filter
+// are passed on to the submodule clones, as they were to the repository.
+func (g *Git) updateSubmodules(ctx context.Context, repoPath string, depth int, filter string, onProgress ProgressFunc) error {
+	if !hasSubmodules(repoPath) || !g.submodulesEnabled(repoPath) {
+		return nil
+	}
+
+	args := []string{"-C", repoPath, "submodule", "update", "--init", "--recursive", "--progress"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	if filter != "" {
+		args = append(args, "--filter="+filter)
+	}
+	if _, err := g.runCommandWithProgress(g.command(ctx, args...), onProgress); err != nil {
+		return fmt.Errorf("submodule update failed: %w", err)
+	}
+	return nil
+}
+
+// isGitlink reports whether the file at gitPath is a ".git" file pointing at
+// a git directory elsewhere, as used by submodules and linked worktrees
+func isGitlink(gitPath string) bool {
+	data, err := os.ReadFile(gitPath)
+	return err == nil && strings.HasPrefix(string(data), "gitdir: ")
+}
@@ -0,0 +1,293 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TagsFileName is the name of the tag store next to the config file
+const TagsFileName = "tags.json"
+
+// tagPattern restricts tag and group names to what is easy to type after "@"
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateTagName checks that a tag or group name can be used as "@name"
+func ValidateTagName(tag string) error {
+	if !tagPattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q (use letters, digits, '.', '_' and '-')", tag)
+	}
+	return nil
+}
+
+// TagStore keeps user-defined tags, each with the names of the repositories
+// carrying it, in a JSON file. It is safe for concurrent use.
+type TagStore struct {
+	path    string
+	mu      sync.Mutex
+	tags    map[string][]string // Tag to sorted slash-separated repository names
+	changed bool                // Modified since loaded or saved
+}
+
+// LoadTagStore reads the store at path. A missing file gives an empty store;
+// unlike fetch results tags cannot be rebuilt, so a broken file is an error.
+func LoadTagStore(path string) (*TagStore, error) {
+	s := &TagStore{path: path, tags: make(map[string][]string)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.tags); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Tag adds a tag to repositories, reporting how many did not have it yet
+func (s *TagStore) Tag(tag string, names []string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, name := range names {
+		name = filepath.ToSlash(name)
+		if !slices.Contains(s.tags[tag], name) {
+			s.tags[tag] = append(s.tags[tag], name)
+			added++
+		}
+	}
+	sort.Strings(s.tags[tag])
+	s.changed = s.changed || added > 0
+	return added
+}
+
+// Untag removes a tag from repositories, reporting how many had it. A tag
+// left without repositories is deleted.
+func (s *TagStore) Untag(tag string, names []string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for _, name := range names {
+		name = filepath.ToSlash(name)
+		if i := slices.Index(s.tags[tag], name); i >= 0 {
+			s.tags[tag] = slices.Delete(s.tags[tag], i, i+1)
+			removed++
+		}
+	}
+	if len(s.tags[tag]) == 0 {
+		delete(s.tags, tag)
+	}
+	s.changed = s.changed || removed > 0
+	return removed
+}
+
+// Rename moves the tags of a repository to its new name, e.g. after relocate
+func (s *TagStore) Rename(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, to = filepath.ToSlash(from), filepath.ToSlash(to)
+	renamed := false
+	for tag, names := range s.tags {
+		if i := slices.Index(names, from); i >= 0 {
+			names[i] = to
+			sort.Strings(names)
+			s.tags[tag] = slices.Compact(names)
+			renamed = true
+		}
+	}
+	s.changed = s.changed || renamed
+}
+
+// Forget removes a repository from every tag, e.g. after it was removed
+func (s *TagStore) Forget(name string) {
+	for _, tag := range s.TagsOf(name) {
+		s.Untag(tag, []string{name})
+	}
+}
+
+// Members returns the repositories carrying a tag
+func (s *TagStore) Members(tag string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.tags[tag])
+}
+
+// TagsOf returns the sorted tags of a repository
+func (s *TagStore) TagsOf(name string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name = filepath.ToSlash(name)
+	var tags []string
+	for tag, names := range s.tags {
+		if slices.Contains(names, name) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Names returns every tag, sorted
+func (s *TagStore) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := make([]string, 0, len(s.tags))
+	for tag := range s.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Save writes the store back to its file if it was modified
+func (s *TagStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.changed {
+		return nil
+	}
+	if s.path == "" {
+		return fmt.Errorf("no configuration directory to keep tags in")
+	}
+
+	data, err := json.MarshalIndent(s.tags, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tags: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tags: %w", err)
+	}
+	s.changed = false
+	return nil
+}
+
+var (
+	groupsMu sync.RWMutex
+	groups   map[string][]string // Group name to selector patterns, from the config
+	tagStore = &TagStore{tags: make(map[string][]string)}
+)
+
+// SetGroups registers named groups, each defined by selector patterns such as
+// "github.com/me" or "!*-archive", usable as "@name" like tags
+func SetGroups(defined map[string][]string) error {
+	validated := make(map[string][]string, len(defined))
+	for name, patterns := range defined {
+		if err := ValidateTagName(name); err != nil {
+			return err
+		}
+		for _, pattern := range patterns {
+			if strings.HasPrefix(strings.TrimPrefix(pattern, "!"), "@") {
+				return fmt.Errorf("group %q: groups cannot refer to other groups or tags", name)
+			}
+			if _, err := compilePattern(strings.TrimPrefix(pattern, "!")); err != nil {
+				return fmt.Errorf("group %q: %w", name, err)
+			}
+		}
+		validated[name] = patterns
+	}
+
+	groupsMu.Lock()
+	groups = validated
+	groupsMu.Unlock()
+	return nil
+}
+
+// SetTagStore installs the tag store "@tag" selectors are resolved against
+func SetTagStore(s *TagStore) {
+	groupsMu.Lock()
+	tagStore = s
+	groupsMu.Unlock()
+}
+
+// Tags returns the installed tag store
+func Tags() *TagStore {
+	groupsMu.RLock()
+	defer groupsMu.RUnlock()
+	return tagStore
+}
+
+// GroupNames returns the names of all configured groups and tags, sorted
+func GroupNames() []string {
+	groupsMu.RLock()
+	names := tagStore.Names()
+	for name := range groups {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	groupsMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// groupMatcher matches the repositories in a configured group or carrying a
+// tag. A name that is both matches either way.
+func groupMatcher(name string) (nameMatcher, error) {
+	groupsMu.RLock()
+	patterns, isGroup := groups[name]
+	store := tagStore
+	groupsMu.RUnlock()
+
+	members := store.Members(name)
+	if !isGroup && len(members) == 0 {
+		return nil, fmt.Errorf("unknown group or tag %q", "@"+name)
+	}
+
+	var includes, excludes []nameMatcher
+	for _, pattern := range patterns {
+		match, err := compilePattern(strings.TrimPrefix(pattern, "!"))
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(pattern, "!") {
+			excludes = append(excludes, match)
+		} else {
+			includes = append(includes, match)
+		}
+	}
+
+	return func(repoName string) bool {
+		if slices.Contains(members, repoName) {
+			return true
+		}
+		if !isGroup {
+			return false
+		}
+		for _, exclude := range excludes {
+			if matchInRoot(exclude, repoName) {
+				return false
+			}
+		}
+		if len(includes) == 0 {
+			return true
+		}
+		for _, include := range includes {
+			if matchInRoot(include, repoName) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
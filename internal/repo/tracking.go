@@ -0,0 +1,174 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"get-repo/internal/debug"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TrackingFileName is the name of the tracking store in the cache directory
+const TrackingFileName = "tracking.json"
+
+// Tracking is how far the checked out branch of a repository is from its
+// upstream branch, as of the last fetch
+type Tracking struct {
+	Ahead     int       `json:"ahead"`  // Local commits not on the upstream branch
+	Behind    int       `json:"behind"` // Upstream commits not on the local branch
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Badge renders the counts for display, e.g. "↓3 ↑1", or "" when the branch
+// is in sync with its upstream
+func (t Tracking) Badge() string {
+	var parts []string
+	if t.Behind > 0 {
+		parts = append(parts, "↓"+strconv.Itoa(t.Behind))
+	}
+	if t.Ahead > 0 {
+		parts = append(parts, "↑"+strconv.Itoa(t.Ahead))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Fetch downloads new commits and tags from the remotes of a repository and
+// prunes deleted remote branches, without touching the working tree.
+// If onProgress is non-nil it receives git's transfer progress.
+func (g *Git) Fetch(ctx context.Context, repoPath string, onProgress ProgressFunc) GitOperation {
+	ctx, cancel := withTimeout(ctx, g.timeouts.Pull)
+	defer cancel()
+
+	cmd := g.command(ctx, "-C", repoPath, "fetch", "--prune", "--progress")
+	output, err := g.runCommandWithProgress(cmd, onProgress)
+	if err != nil {
+		err = contextError(ctx, "fetch", g.timeouts.Pull, err)
+	}
+
+	return GitOperation{
+		Success: err == nil,
+		Output:  output,
+		Error:   err,
+	}
+}
+
+// AheadBehind counts the commits between the checked out branch and its
+// upstream branch using local refs only. It fails for a detached HEAD or a
+// branch without upstream.
+func (g *Git) AheadBehind(ctx context.Context, repoPath string) (Tracking, error) {
+	ctx, cancel := withTimeout(ctx, g.timeouts.Status)
+	defer cancel()
+
+	cmd := g.command(ctx, "-C", repoPath, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	output, err := g.runCommand(cmd)
+	if err != nil {
+		return Tracking{}, contextError(ctx, "rev-list", g.timeouts.Status, err)
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return Tracking{}, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(output))
+	}
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Tracking{}, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(output))
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Tracking{}, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(output))
+	}
+	return Tracking{Ahead: ahead, Behind: behind, FetchedAt: time.Now()}, nil
+}
+
+// TrackingStore keeps the last known Tracking of each repository, by name
+// below the base path, in a JSON file. It is safe for concurrent use.
+type TrackingStore struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]Tracking
+}
+
+// LoadTrackingStore reads the store at path. A missing or unreadable file
+// gives an empty store, since fetching again rebuilds it.
+func LoadTrackingStore(path string) *TrackingStore {
+	s := &TrackingStore{path: path, entries: make(map[string]Tracking)}
+	if path == "" {
+		return s
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.LogError(err, "reading tracking store")
+		}
+		return s
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		debug.LogError(err, "parsing tracking store")
+		s.entries = make(map[string]Tracking)
+	}
+	return s
+}
+
+// Get returns the stored tracking of a repository
+func (s *TrackingStore) Get(name string) (Tracking, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.entries[filepath.ToSlash(name)]
+	return t, ok
+}
+
+// Set records the tracking of a repository
+func (s *TrackingStore) Set(name string, t Tracking) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[filepath.ToSlash(name)] = t
+}
+
+// Delete forgets a repository, e.g. once it has no upstream
+func (s *TrackingStore) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, filepath.ToSlash(name))
+}
+
+// Save writes the store back to its file
+func (s *TrackingStore) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode tracking store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tracking store: %w", err)
+	}
+	return nil
+}
+
+// RecordTracking recomputes the tracking of a repository into store and
+// returns it. Repositories without an upstream branch are forgotten and
+// reported with false.
+func (g *Git) RecordTracking(ctx context.Context, store *TrackingStore, name, repoPath string) (Tracking, bool) {
+	t, err := g.AheadBehind(ctx, repoPath)
+	if err != nil {
+		debug.Log("No tracking for %s: %v", name, err)
+		store.Delete(name)
+		return Tracking{}, false
+	}
+	store.Set(name, t)
+	return t, true
+}
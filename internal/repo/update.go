@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"get-repo/internal/debug"
+	"strings"
+)
+
+// UpdateStrategy selects how Pull integrates upstream changes
+type UpdateStrategy string
+
+const (
+	StrategyFFOnly    UpdateStrategy = "ff-only"   // Only fast-forward; never creates commits (default)
+	StrategyRebase    UpdateStrategy = "rebase"    // Rebase local commits onto upstream
+	StrategyAutostash UpdateStrategy = "autostash" // Rebase, stashing local changes around it
+)
+
+// UpdateStrategies lists the valid strategies, default first
+var UpdateStrategies = []UpdateStrategy{StrategyFFOnly, StrategyRebase, StrategyAutostash}
+
+// ParseUpdateStrategy validates a strategy name; empty means StrategyFFOnly
+func ParseUpdateStrategy(name string) (UpdateStrategy, error) {
+	if name == "" {
+		return StrategyFFOnly, nil
+	}
+	for _, s := range UpdateStrategies {
+		if UpdateStrategy(strings.ToLower(name)) == s {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown update strategy %q (use ff-only, rebase or autostash)", name)
+}
+
+// pullArgs returns the git pull options implementing the strategy
+func (s UpdateStrategy) pullArgs() []string {
+	switch s {
+	case StrategyRebase:
+		return []string{"--rebase"}
+	case StrategyAutostash:
+		return []string{"--rebase", "--autostash"}
+	default:
+		return []string{"--ff-only"}
+	}
+}
+
+// Reasons an update is skipped rather than attempted
+const (
+	SkipDirty      = "dirty"       // Uncommitted changes to tracked files
+	SkipDetached   = "detached"    // HEAD is not on a branch
+	SkipNoUpstream = "no upstream" // The branch does not track a remote branch
+	SkipSubmodule  = "submodule"   // Updated with its superproject instead
+)
+
+// skipReason checks whether a repository can be updated safely with the
+// given strategy, returning why not or "" if it can. Only autostash may
+// update a working tree with uncommitted changes; untracked files do not
+// count, as neither a fast-forward nor a rebase touches them.
+func (g *Git) skipReason(ctx context.Context, repoPath string, strategy UpdateStrategy) string {
+	if strategy != StrategyAutostash && g.hasTrackedChanges(ctx, repoPath) {
+		return SkipDirty
+	}
+	if _, err := g.runCommand(g.command(ctx, "-C", repoPath, "symbolic-ref", "--quiet", "HEAD")); err != nil {
+		return SkipDetached
+	}
+	if _, err := g.runCommand(g.command(ctx, "-C", repoPath, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")); err != nil {
+		return SkipNoUpstream
+	}
+	return ""
+}
+
+// hasTrackedChanges reports whether tracked files have uncommitted changes,
+// staged or not
+func (g *Git) hasTrackedChanges(ctx context.Context, repoPath string) bool {
+	output, err := g.runCommand(g.command(ctx, "-C", repoPath, "status", "--porcelain", "--untracked-files=no"))
+	return err == nil && strings.TrimSpace(output) != ""
+}
+
+// abortRebase undoes a rebase that stopped on conflicts, so a failed update
+// leaves the working tree as it was. It does nothing if no rebase is in
+// progress, e.g. when the fetch failed.
+func (g *Git) abortRebase(repoPath string) {
+	cmd := g.command(context.Background(), "-C", repoPath, "rebase", "--abort")
+	if _, err := g.runCommand(cmd); err == nil {
+		debug.Log("Aborted conflicting rebase in %s", repoPath)
+	}
+}
+
+// hasConflicts reports whether the working tree has unmerged paths
+func (g *Git) hasConflicts(ctx context.Context, repoPath string) bool {
+	output, err := g.runCommand(g.command(ctx, "-C", repoPath, "ls-files", "--unmerged"))
+	return err == nil && strings.TrimSpace(output) != ""
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testCheckout clones a fresh repository with one commit, so its branch
+// tracks origin, and returns the checkout's path
+func testCheckout(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	dir := t.TempDir()
+	origin := filepath.Join(dir, "origin")
+	if err := os.Mkdir(origin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	run(origin, "init", "-q")
+	if err := os.WriteFile(filepath.Join(origin, "README"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run(origin, "add", "README")
+	run(origin, "commit", "-q", "-m", "initial")
+	run(dir, "clone", "-q", "origin", "checkout")
+	return filepath.Join(dir, "checkout")
+}
+
+func TestSkipReasonUntrackedFiles(t *testing.T) {
+	checkout := testCheckout(t)
+	g := NewGit(filepath.Dir(checkout))
+	ctx := context.Background()
+
+	if got := g.skipReason(ctx, checkout, StrategyFFOnly); got != "" {
+		t.Fatalf("clean checkout skipped: %q", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(checkout, "build.out"), []byte("artifact\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, strategy := range UpdateStrategies {
+		if got := g.skipReason(ctx, checkout, strategy); got != "" {
+			t.Errorf("%s: checkout with only untracked files skipped: %q", strategy, got)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(checkout, "README"), []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, strategy := range []UpdateStrategy{StrategyFFOnly, StrategyRebase} {
+		if got := g.skipReason(ctx, checkout, strategy); got != SkipDirty {
+			t.Errorf("%s: checkout with a modified file: skip reason %q, want %q", strategy, got, SkipDirty)
+		}
+	}
+	if got := g.skipReason(ctx, checkout, StrategyAutostash); got != "" {
+		t.Errorf("autostash: checkout with a modified file skipped: %q", got)
+	}
+}
@@ -0,0 +1,317 @@
+package repo
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// URL schemes understood by git
+const (
+	SchemeHTTPS = "https"
+	SchemeHTTP  = "http"
+	SchemeSSH   = "ssh"
+	SchemeGit   = "git"
+	SchemeFile  = "file"
+	SchemeFTP   = "ftp"
+	SchemeFTPS  = "ftps"
+)
+
+// localHost is the clone path prefix used for repositories on the local
+// filesystem, which have no host of their own
+const localHost = "local"
+
+// defaultPorts are dropped during normalization so that, for example,
+// https://host:443/repo and https://host/repo are the same repository
+var defaultPorts = map[string]string{
+	SchemeHTTPS: "443",
+	SchemeHTTP:  "80",
+	SchemeSSH:   "22",
+	SchemeGit:   "9418",
+	SchemeFTP:   "21",
+	SchemeFTPS:  "990",
+}
+
+// scpPattern matches git's scp-like syntax: [user@]host:path.
+// Like git, a slash before the first colon means it is a local path instead.
+var scpPattern = regexp.MustCompile(`^(?:([^@/]+)@)?([^@/:\[\]]+|\[[^\]]+\]):(.*)$`)
+
+// RemoteURL is a parsed git remote in any of the forms git accepts:
+// scheme URLs (https, http, ssh, git, file, ftp(s)), scp-like
+// user@host:path, and local paths.
+type RemoteURL struct {
+	Scheme   string        // One of the Scheme* constants; scp-like remotes are SchemeSSH
+	User     *url.Userinfo // e.g. "git", or a user and token; nil if not given
+	Host     string        // Lowercased host name; empty for local repositories
+	Port     string        // Non-default port, empty otherwise
+	Segments []string      // Unescaped path segments with any ".git" suffix removed
+	SCPLike  bool          // Written as user@host:path rather than ssh://
+	Local    bool          // File URL or plain filesystem path
+	Ref      string        // Branch, tag or commit requested with "@ref" or "#ref"
+	Subpath  string        // File or directory inside the repository a browser link points at
+	absolute bool          // Path was rooted (local paths, scp-like "host:/path")
+	suffix   string        // ".git" if the remote path had it; servers may need it
+	path     string        // Absolute filesystem path for plain local paths
+	escaped  []string      // Segments as escaped in a scheme URL, used to rebuild it
+	refPath  string        // Ref and Subpath of a browser link, until split by the remote's refs
+}
+
+// ParseRemoteURL parses and normalizes a git remote URL.
+// Short notation must be expanded first (see ExpandShortNotation).
+func ParseRemoteURL(raw string) (RemoteURL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return RemoteURL{}, fmt.Errorf("empty URL provided")
+	}
+
+	var u RemoteURL
+	switch {
+	case strings.Contains(raw, "://"):
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return RemoteURL{}, fmt.Errorf("invalid URL: %w", err)
+		}
+		u.Scheme = strings.ToLower(parsed.Scheme)
+		if _, ok := defaultPorts[u.Scheme]; !ok && u.Scheme != SchemeFile {
+			return RemoteURL{}, fmt.Errorf("unsupported URL scheme %q", parsed.Scheme)
+		}
+		// Passwords and tokens are kept, since git needs them to clone
+		u.User = parsed.User
+		u.Host = strings.ToLower(parsed.Hostname())
+		u.Port = parsed.Port()
+		if u.Port == defaultPorts[u.Scheme] {
+			u.Port = ""
+		}
+		u.Local = u.Scheme == SchemeFile
+		u.absolute = u.Local
+		u.escaped = splitSegments(parsed.EscapedPath())
+		u.Segments = unescapeSegments(u.escaped)
+
+	case isLocalPath(raw):
+		// Relative paths are resolved, so the same repository has the same
+		// identity wherever get-repo runs
+		if !filepath.IsAbs(raw) && !hasDriveLetter(raw) {
+			abs, err := filepath.Abs(raw)
+			if err != nil {
+				return RemoteURL{}, fmt.Errorf("invalid path %s: %w", raw, err)
+			}
+			raw = abs
+		}
+		u.Scheme = SchemeFile
+		u.Local = true
+		u.absolute = true
+		u.path = raw
+		u.Segments = splitSegments(raw)
+		if len(u.Segments) > 0 {
+			// Keep the drive letter of Windows paths, but not its colon
+			u.Segments[0] = strings.TrimSuffix(u.Segments[0], ":")
+		}
+
+	default:
+		match := scpPattern.FindStringSubmatch(raw)
+		if match == nil {
+			return RemoteURL{}, fmt.Errorf("unsupported URL format: %s", raw)
+		}
+		u.Scheme = SchemeSSH
+		u.SCPLike = true
+		if match[1] != "" {
+			u.User = url.User(match[1])
+		}
+		u.Host = strings.ToLower(strings.Trim(match[2], "[]"))
+		u.absolute = strings.HasPrefix(match[3], "/")
+		u.Segments = splitSegments(match[3])
+	}
+
+	if !u.Local && u.Host == "" {
+		return RemoteURL{}, fmt.Errorf("URL has no host: %s", raw)
+	}
+	if len(u.Segments) == 0 {
+		return RemoteURL{}, fmt.Errorf("URL has no repository path: %s", raw)
+	}
+	if strings.HasSuffix(strings.TrimRight(raw, "/"), ".git") {
+		u.suffix = ".git"
+	}
+	for _, segment := range u.Segments {
+		if segment == ".." {
+			return RemoteURL{}, fmt.Errorf("URL path must not contain '..': %s", raw)
+		}
+	}
+
+	return u, nil
+}
+
+// ResolveURL splits off any ref suffix, expands short notation and parses
+// the result. Browser links into a repository (see parseWebURL) resolve to
+// the repository itself, with the ref and path they point at.
+func ResolveURL(input string) (RemoteURL, error) {
+	if u, ok := parseWebURL(input); ok {
+		return u, nil
+	}
+
+	input, ref := SplitRef(input)
+	u, err := ParseRemoteURL(ExpandShortNotation(input))
+	if err != nil {
+		return RemoteURL{}, err
+	}
+	u.Ref = ref
+	return u, nil
+}
+
+// SplitRef separates a ref suffix from a URL or shorthand:
+// "gh:user/repo@v1.4.2" and "gh:user/repo#feature-x" name a tag and a branch.
+// An "@" only counts once the repository path has started, so the user part
+// of "git@host:path" or "ssh://git@host/path" is left alone.
+func SplitRef(input string) (url, ref string) {
+	if i := strings.Index(input, "#"); i != -1 {
+		return input[:i], input[i+1:]
+	}
+
+	// Find where the repository path starts
+	start := -1
+	if i := strings.Index(input, "://"); i != -1 {
+		if j := strings.Index(input[i+3:], "/"); j != -1 {
+			start = i + 3 + j
+		}
+	} else {
+		start = strings.IndexAny(input, ":/\\")
+	}
+	if start == -1 {
+		return input, ""
+	}
+
+	if i := strings.Index(input[start:], "@"); i != -1 {
+		return input[:start+i], input[start+i+1:]
+	}
+	return input, ""
+}
+
+// splitSegments splits a URL path into clean segments, dropping empty and
+// "." parts and a trailing ".git" suffix
+func splitSegments(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(strings.ReplaceAll(p, "\\", "/"), "/") {
+		if segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	if n := len(segments); n > 0 {
+		segments[n-1] = strings.TrimSuffix(segments[n-1], ".git")
+		if segments[n-1] == "" {
+			segments = segments[:n-1]
+		}
+	}
+	return segments
+}
+
+// unescapeSegments decodes escaped URL path segments. A segment that does
+// not decode, or decodes to something containing a path separator (such as
+// "%2F"), is kept escaped so it stays a single directory.
+func unescapeSegments(escaped []string) []string {
+	segments := make([]string, len(escaped))
+	for i, segment := range escaped {
+		segments[i] = segment
+		if decoded, err := url.PathUnescape(segment); err == nil && !strings.ContainsAny(decoded, "/\\") {
+			segments[i] = decoded
+		}
+	}
+	return segments
+}
+
+// hasDriveLetter reports whether raw starts with a Windows drive, e.g. "C:\"
+func hasDriveLetter(raw string) bool {
+	return len(raw) >= 3 && raw[1] == ':' && (raw[2] == '\\' || raw[2] == '/')
+}
+
+// isLocalPath reports whether raw is a filesystem path rather than a remote.
+// Like git, anything with a slash before the first colon is a path; Windows
+// drive paths (C:\ or C:/) are paths too.
+func isLocalPath(raw string) bool {
+	if hasDriveLetter(raw) {
+		return true
+	}
+	colon := strings.Index(raw, ":")
+	slash := strings.IndexAny(raw, "/\\")
+	return slash != -1 && (colon == -1 || slash < colon)
+}
+
+// Path returns the repository path, e.g. "org/group/repo"
+func (u RemoteURL) Path() string {
+	return strings.Join(u.Segments, "/")
+}
+
+// Owner returns the path segments before the repository name, e.g. "org/group"
+func (u RemoteURL) Owner() string {
+	return strings.Join(u.Segments[:len(u.Segments)-1], "/")
+}
+
+// Name returns the repository name, the last path segment
+func (u RemoteURL) Name() string {
+	return u.Segments[len(u.Segments)-1]
+}
+
+// Matches reports whether raw, such as the origin URL of an existing
+// checkout, refers to the same repository. The URL git would be given after
+// rewrites counts as well, so mirrors configured with url_rewrites match.
+func (u RemoteURL) Matches(raw string) bool {
+	if raw == u.CloneURL() {
+		return true
+	}
+	other, err := ParseRemoteURL(raw)
+	return err == nil && other.Key() == u.Key()
+}
+
+// String returns the remote in a form git accepts, preserving the original
+// style (scp-like or scheme URL) but with normalized host, port and path
+func (u RemoteURL) String() string {
+	if u.path != "" {
+		return u.path
+	}
+
+	if u.SCPLike {
+		p := u.Path() + u.suffix
+		if u.absolute {
+			p = "/" + p
+		}
+		host := u.Host
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		if u.User != nil {
+			host = u.User.Username() + "@" + host
+		}
+		return host + ":" + p
+	}
+
+	p := u.escapedPath() + u.suffix
+	if u.Local {
+		return "file:///" + p
+	}
+
+	host := u.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if u.Port != "" {
+		host += ":" + u.Port
+	}
+	if u.User != nil {
+		host = u.User.String() + "@" + host
+	}
+	return u.Scheme + "://" + host + "/" + p
+}
+
+// escapedPath returns the path of a scheme URL with its segments escaped as
+// they were given, or escaped anew where Segments no longer matches them
+func (u RemoteURL) escapedPath() string {
+	parts := make([]string, len(u.Segments))
+	for i, segment := range u.Segments {
+		if i < len(u.escaped) && unescapeSegments(u.escaped[i : i+1])[0] == segment {
+			parts[i] = u.escaped[i]
+		} else {
+			parts[i] = url.PathEscape(segment)
+		}
+	}
+	return strings.Join(parts, "/")
+}
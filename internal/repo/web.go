@@ -0,0 +1,213 @@
+package repo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Browser links point at pages inside a repository rather than at the
+// repository itself, e.g. https://github.com/org/repo/tree/main/pkg or
+// https://gitlab.com/group/repo/-/merge_requests/7. The repository root is
+// found where the hosting software's route segment begins.
+
+// Web styles name the URL shape of a host's browser links
+const (
+	WebGitHub    = "github"    // owner/repo followed by a route, e.g. /tree/<ref>
+	WebGitea     = "gitea"     // Like GitHub, with /src/branch/<ref>
+	WebBitbucket = "bitbucket" // Like GitHub, with /src/<ref>
+	WebGitLab    = "gitlab"    // Nested groups; routes follow a "-" segment
+)
+
+// webStyles are the hosts whose browser links are known to have a style.
+// Providers can declare the style of others (see Provider.Web).
+var webStyles = map[string]string{
+	"github.com":    WebGitHub,
+	"bitbucket.org": WebBitbucket,
+	"codeberg.org":  WebGitea,
+	"gitea.com":     WebGitea,
+	"gitlab.com":    WebGitLab,
+}
+
+// webStyle returns the style of browser links on host, "" if unknown
+func webStyle(host string) string {
+	for _, p := range Providers() {
+		if p.Web != "" && strings.EqualFold(hostName(p.Host), host) {
+			return p.Web
+		}
+	}
+	return webStyles[host]
+}
+
+// hostName strips the port from a provider host such as "git.example:8443"
+func hostName(host string) string {
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.Contains(host[i:], "]") {
+		return strings.Trim(host[:i], "[]")
+	}
+	return strings.Trim(host, "[]")
+}
+
+// parseWebURL recognizes a browser link to a page inside a repository. It
+// returns the repository with Ref and Subpath set from the link, and false for
+// anything that is not such a link.
+func parseWebURL(input string) (RemoteURL, bool) {
+	lower := strings.ToLower(input)
+	if !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "http://") {
+		return RemoteURL{}, false
+	}
+
+	// Anchors (#L10) and queries (?plain=1) are page details, never refs
+	if i := strings.IndexAny(input, "#?"); i != -1 {
+		input = input[:i]
+	}
+	u, err := ParseRemoteURL(input)
+	if err != nil || u.suffix != "" {
+		return RemoteURL{}, false
+	}
+
+	switch webStyle(u.Host) {
+	case WebGitHub, WebGitea, WebBitbucket:
+		// Routes follow owner/repo directly, and as these hosts have no
+		// other paths, any further segment is a page (issues, wiki, ...)
+		if len(u.Segments) <= 2 {
+			return RemoteURL{}, false
+		}
+		if parse, ok := webRoutes[u.Segments[2]]; ok && len(u.Segments) > 3 {
+			return u.webLink(2, u.Segments[2:], parse), true
+		}
+		return u.webLink(2, u.Segments[2:], noRef), true
+
+	default:
+		// GitLab, and hosts of unknown style: everything from the "-"
+		// segment on is a route. GitLab reserves the name, so no group or
+		// project can be called "-".
+		for i := 2; i < len(u.Segments); i++ {
+			if u.Segments[i] == "-" {
+				return u.webLink(i, u.Segments[i+1:], gitlabRoute), true
+			}
+		}
+	}
+	return RemoteURL{}, false
+}
+
+// webRoute extracts the ref and in-repository path from the segments of a
+// route, starting with the route name (e.g. "tree", "main", "pkg")
+type webRoute func(route []string) (ref, subpath string)
+
+// webRoutes are the GitHub, Gitea and Bitbucket routes that carry a ref
+var webRoutes = map[string]webRoute{
+	"tree":     refAndPath,
+	"blob":     refAndPath,
+	"blame":    refAndPath,
+	"raw":      refAndPath,
+	"commit":   refOnly,
+	"commits":  refOnly,
+	"releases": releaseRoute,
+	"src":      srcRoute,
+	"pull":     pullRoute("refs/pull/%s/head"),
+	"pulls":    pullRoute("refs/pull/%s/head"),
+	// Bitbucket does not publish pull request refs
+	"pull-requests": noRef,
+}
+
+// noRef handles routes that do not name a ref, such as issues
+func noRef([]string) (string, string) {
+	return "", ""
+}
+
+// gitlabRoute handles the segments after GitLab's "/-/"
+func gitlabRoute(route []string) (string, string) {
+	if len(route) < 2 {
+		return "", ""
+	}
+	switch route[0] {
+	case "tree", "blob", "blame", "raw":
+		return refAndPath(route)
+	case "commit", "commits", "tags":
+		return refOnly(route)
+	case "merge_requests":
+		return pullRoute("refs/merge-requests/%s/head")(route)
+	}
+	return "", ""
+}
+
+// refAndPath handles ".../tree/<ref>/<path>" style routes. Branch names that
+// contain slashes cannot be told apart from the path here, so the ref is cut
+// at the first slash until ResolveLinkRef asks the remote where it ends.
+func refAndPath(route []string) (string, string) {
+	if len(route) < 2 {
+		return "", ""
+	}
+	return route[1], strings.Join(route[2:], "/")
+}
+
+// refOnly handles ".../commit/<sha>" style routes
+func refOnly(route []string) (string, string) {
+	if len(route) < 2 {
+		return "", ""
+	}
+	return route[1], ""
+}
+
+// releaseRoute handles GitHub's ".../releases/tag/<tag>"
+func releaseRoute(route []string) (string, string) {
+	if len(route) < 3 || route[1] != "tag" {
+		return "", ""
+	}
+	return route[2], ""
+}
+
+// srcRoute handles Gitea's ".../src/branch/<ref>/<path>" (also tag and
+// commit) and Bitbucket's ".../src/<ref>/<path>"
+func srcRoute(route []string) (string, string) {
+	if len(route) > 2 && (route[1] == "branch" || route[1] == "tag" || route[1] == "commit") {
+		route = route[1:]
+	}
+	return refAndPath(route)
+}
+
+// pullRoute handles pull and merge request routes, which are checked out
+// from the server's ref for them
+func pullRoute(refFormat string) webRoute {
+	return func(route []string) (string, string) {
+		if len(route) < 2 {
+			return "", ""
+		}
+		return fmt.Sprintf(refFormat, route[1]), ""
+	}
+}
+
+// webLink trims the route off u, keeping its ref and the path it points at
+func (u RemoteURL) webLink(routeStart int, route []string, parse webRoute) RemoteURL {
+	u.Segments = u.Segments[:routeStart]
+	u.Ref, u.Subpath = parse(route)
+	if u.Subpath != "" {
+		u.refPath = path.Join(u.Ref, u.Subpath)
+	}
+	return u
+}
+
+// HasAmbiguousRef reports whether the remote comes from a browser link whose
+// ref may continue into the path, as in ".../tree/feature/login/src"
+func (u RemoteURL) HasAmbiguousRef() bool {
+	return u.refPath != ""
+}
+
+// splitRefPath splits the ref and path of a browser link at the longest
+// prefix that is one of the refs, branch and tag names without their
+// "refs/heads/" or "refs/tags/" prefix. Without a match the first segment
+// stays the ref, since it may be a commit.
+func (u RemoteURL) splitRefPath(refs map[string]bool) RemoteURL {
+	if u.refPath == "" {
+		return u
+	}
+	segments := strings.Split(u.refPath, "/")
+	for n := len(segments); n > 0; n-- {
+		if ref := strings.Join(segments[:n], "/"); refs[ref] {
+			u.Ref, u.Subpath = ref, strings.Join(segments[n:], "/")
+			break
+		}
+	}
+	u.refPath = ""
+	return u
+}
@@ -0,0 +1,119 @@
+package repo
+
+import "testing"
+
+func TestParseWebURL(t *testing.T) {
+	tests := []struct {
+		link      string
+		key       string
+		ref       string
+		subpath   string
+		ambiguous bool
+	}{
+		{link: "https://github.com/org/repo/tree/main", key: "github.com/org/repo", ref: "main"},
+		{link: "https://github.com/org/repo/tree/main/pkg/api", key: "github.com/org/repo", ref: "main", subpath: "pkg/api", ambiguous: true},
+		{link: "https://github.com/org/repo/blob/v1.2.0/README.md#L10", key: "github.com/org/repo", ref: "v1.2.0", subpath: "README.md", ambiguous: true},
+		{link: "https://github.com/org/repo/commit/0123abc", key: "github.com/org/repo", ref: "0123abc"},
+		{link: "https://github.com/org/repo/releases/tag/v2", key: "github.com/org/repo", ref: "v2"},
+		{link: "https://github.com/org/repo/pull/42/files", key: "github.com/org/repo", ref: "refs/pull/42/head"},
+		{link: "https://github.com/org/repo/issues/7", key: "github.com/org/repo"},
+		{link: "https://github.com/org/repo/tree", key: "github.com/org/repo"},
+		{link: "https://codeberg.org/org/repo/src/branch/dev/cmd", key: "codeberg.org/org/repo", ref: "dev", subpath: "cmd", ambiguous: true},
+		{link: "https://bitbucket.org/team/repo/src/main/lib?at=main", key: "bitbucket.org/team/repo", ref: "main", subpath: "lib", ambiguous: true},
+		{link: "https://gitlab.com/group/sub/repo/-/tree/main/src", key: "gitlab.com/group/sub/repo", ref: "main", subpath: "src", ambiguous: true},
+		{link: "https://gitlab.com/group/repo/-/merge_requests/7", key: "gitlab.com/group/repo", ref: "refs/merge-requests/7/head"},
+		{link: "https://git.corp.example/group/repo/-/commit/abcdef0", key: "git.corp.example/group/repo", ref: "abcdef0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			u, ok := parseWebURL(tt.link)
+			if !ok {
+				t.Fatalf("parseWebURL(%q) did not recognize the link", tt.link)
+			}
+			if u.Key() != tt.key || u.Ref != tt.ref || u.Subpath != tt.subpath {
+				t.Errorf("got key %q ref %q subpath %q, want %q %q %q", u.Key(), u.Ref, u.Subpath, tt.key, tt.ref, tt.subpath)
+			}
+			if got := u.HasAmbiguousRef(); got != tt.ambiguous {
+				t.Errorf("HasAmbiguousRef() = %v, want %v", got, tt.ambiguous)
+			}
+		})
+	}
+}
+
+func TestParseWebURLNotLinks(t *testing.T) {
+	for _, raw := range []string{
+		"https://github.com/org/repo",
+		"https://github.com/org/repo.git",
+		"git@github.com:org/repo.git",
+		"gh:org/repo",
+		// Self-hosted GitLab nested groups named like GitHub routes
+		"https://git.corp.example/group/tree/main/repo",
+		"https://gitlab.com/group/blob/repo",
+	} {
+		if u, ok := parseWebURL(raw); ok {
+			t.Errorf("parseWebURL(%q) = %q (ref %q), want no link", raw, u.Key(), u.Ref)
+		}
+	}
+}
+
+func TestParseWebURLProviderStyle(t *testing.T) {
+	if err := SetProviders([]Provider{{Name: "forge", Host: "forge.example:8443", Web: WebGitea}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetProviders(nil) })
+
+	u, ok := parseWebURL("https://forge.example:8443/org/repo/src/tag/v1/docs")
+	if !ok {
+		t.Fatal("link on a provider marked as Gitea was not recognized")
+	}
+	if u.Key() != "forge.example/org/repo" || u.Ref != "v1" || u.Subpath != "docs" {
+		t.Errorf("got key %q ref %q subpath %q", u.Key(), u.Ref, u.Subpath)
+	}
+
+	if err := SetProviders([]Provider{{Name: "bad", Host: "x.example", Web: "sourcehut"}}); err == nil {
+		t.Error("SetProviders accepted an unknown web style")
+	}
+}
+
+func TestSplitRefPath(t *testing.T) {
+	refs := map[string]bool{"main": true, "feature": true, "feature/login": true, "v1.0": true}
+	tests := []struct {
+		link    string
+		ref     string
+		subpath string
+	}{
+		{link: "https://github.com/o/r/tree/feature/login/src/app", ref: "feature/login", subpath: "src/app"},
+		{link: "https://github.com/o/r/tree/feature/login", ref: "feature/login"},
+		{link: "https://github.com/o/r/tree/feature/other/file", ref: "feature", subpath: "other/file"},
+		{link: "https://github.com/o/r/blob/main/docs/a.md", ref: "main", subpath: "docs/a.md"},
+		{link: "https://github.com/o/r/tree/0123abcd/src", ref: "0123abcd", subpath: "src"},
+	}
+
+	for _, tt := range tests {
+		u, ok := parseWebURL(tt.link)
+		if !ok {
+			t.Fatalf("parseWebURL(%q) did not recognize the link", tt.link)
+		}
+		u = u.splitRefPath(refs)
+		if u.Ref != tt.ref || u.Subpath != tt.subpath {
+			t.Errorf("%s: got ref %q subpath %q, want %q %q", tt.link, u.Ref, u.Subpath, tt.ref, tt.subpath)
+		}
+		if u.HasAmbiguousRef() {
+			t.Errorf("%s: still ambiguous after splitting", tt.link)
+		}
+	}
+}
+
+func TestRefNames(t *testing.T) {
+	output := "abc\trefs/heads/main\nabc\trefs/heads/feature/login\ndef\trefs/tags/v1.0\ndef\trefs/tags/v1.0^{}\nxyz\trefs/pull/1/head\n"
+	got := refNames(output, "refs/heads/", "refs/tags/")
+	for _, want := range []string{"main", "feature/login", "v1.0"} {
+		if !got[want] {
+			t.Errorf("refNames is missing %q: %v", want, got)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("refNames = %v, want 3 names", got)
+	}
+}
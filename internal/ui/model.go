@@ -1,15 +1,19 @@
 package ui
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"get-repo/config"
 	"get-repo/internal/debug"
+	"get-repo/internal/jobs"
 	"get-repo/internal/repo"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
@@ -36,6 +40,8 @@ const (
 	StateUpdateSelection
 	StateRemoveSelection
 	StateBatchOperation
+	StateCommandSelect
+	StateSelectPattern
 )
 
 // Model represents the main TUI model
@@ -51,23 +57,66 @@ type Model struct {
 	selected    map[int]struct{}
 	manager     *repo.Manager
 	git         *repo.Git
+	tracking    *repo.TrackingStore
+	scheduler   *jobs.Scheduler
 	setupWizard SetupWizard
 
 	// Batch operation tracking
 	totalOps         int
 	completedOps     int
 	operationResults []OperationResult
-	operationMutex   sync.Mutex
+	operationMutex   *sync.Mutex
+
+	// Cancellation for in-flight clone and batch operations
+	opCtx     context.Context
+	cancelOps context.CancelFunc
+
+	// Live git progress from running clone/pull commands
+	progressCh    chan progressMsg
+	cloneProgress *repo.Progress
 
 	// Batch removal tracking
 	batchRemoveRepos []string
+
+	// Index into clonePresets chosen on the clone screen
+	clonePreset int
+
+	// Repositories a saved command is about to run in, and the command
+	// highlighted on the command screen
+	execTargets  []string
+	commandIndex int
+
+	// Show the repositories grouped by tag instead of by directory
+	tagView bool
+
+	// Startup scan, nil once it has finished, and what it found so far
+	scanCh  chan scanBatchMsg
+	scanned []repo.Repository
+}
+
+// clonePreset is a set of clone options offered on the clone screen
+type clonePreset struct {
+	name    string
+	options repo.CloneOptions
+}
+
+// clonePresets are the clone modes the clone screen cycles through.
+// The default leaves everything to the configured clone defaults.
+var clonePresets = []clonePreset{
+	{name: "Default"},
+	{name: "Shallow (depth 1)", options: repo.CloneOptions{Depth: 1}},
+	{name: "Shallow, single branch", options: repo.CloneOptions{Depth: 1, SingleBranch: true}},
+	{name: "Blobless (blob:none)", options: repo.CloneOptions{Filter: repo.FilterBlobless}},
+	{name: "Treeless (tree:0)", options: repo.CloneOptions{Filter: repo.FilterTreeless}},
 }
 
 // OperationResult tracks the result of a batch operation
 type OperationResult struct {
-	RepoName string
-	Success  bool
-	Message  string
+	RepoName  string
+	Success   bool
+	Cancelled bool
+	Skipped   bool // Not attempted because it could not be done safely
+	Message   string
 }
 
 // OperationStatus represents the status of an operation on a repository
@@ -78,6 +127,8 @@ const (
 	StatusPending
 	StatusSuccess
 	StatusFailed
+	StatusCancelled
+	StatusSkipped
 )
 
 // TreeNode represents a node in the repository tree
@@ -85,12 +136,15 @@ type TreeNode struct {
 	Name       string
 	Path       string
 	IsRepo     bool
+	Kind       repo.Kind // Worktree, bare repository or submodule, for repositories
 	IsExpanded bool
 	Level      int
 	Children   []*TreeNode
 	Parent     *TreeNode
 	Status     OperationStatus
 	StatusMsg  string
+	Progress   *repo.Progress // Latest git progress while an operation runs
+	Tracking   *repo.Tracking // Ahead/behind counts from the last fetch or update
 }
 
 // Item represents a list item (flattened tree view)
@@ -143,10 +197,17 @@ func (i Item) Title() string {
 	switch i.status {
 	case StatusPending:
 		statusIcon = "••• " // Three dots for pending
+		if i.node != nil && i.node.Progress != nil {
+			statusIcon = fmt.Sprintf("%3d%% ", int(i.node.Progress.Overall()*100))
+		}
 	case StatusSuccess:
 		statusIcon = "✓ " // Simple check mark
 	case StatusFailed:
 		statusIcon = "✗ " // Simple X mark
+	case StatusCancelled:
+		statusIcon = "⊘ " // Slashed circle for cancelled
+	case StatusSkipped:
+		statusIcon = "↷ " // Curved arrow for skipped
 	default:
 		statusIcon = ""
 	}
@@ -155,6 +216,16 @@ func (i Item) Title() string {
 	if i.isGitRepo {
 		typeIcon = ""
 		color = "#4ec9b0" // Git repo color
+		if i.node != nil {
+			switch i.node.Kind {
+			case repo.KindWorktree:
+				color = "#c586c0" // Purple for linked worktrees
+			case repo.KindBare:
+				color = "#9cdcfe" // Light blue for bare repositories
+			case repo.KindSubmodule:
+				color = "#8a8a8a" // Gray for submodules, updated with their superproject
+			}
+		}
 
 		// Override color based on status
 		switch i.status {
@@ -164,6 +235,10 @@ func (i Item) Title() string {
 			color = "#ff5f5f" // Bright red for failure
 		case StatusPending:
 			color = "#ffff5f" // Yellow for pending
+		case StatusCancelled:
+			color = "#808080" // Gray for cancelled
+		case StatusSkipped:
+			color = "#d7af5f" // Amber for skipped
 		}
 	} else if i.isExpandable {
 		typeIcon = ""
@@ -182,7 +257,23 @@ func (i Item) Title() string {
 	// Build the title with status
 	title := fmt.Sprintf("%s%s%s%s%s %s", selectionIndicator, indent, expandIcon, statusIcon, typeIcon, i.name)
 
-	// Don't show error message inline - it's shown at the bottom
+	// Anything but a normal checkout is labelled, e.g. "[worktree]"
+	if i.isGitRepo && i.node != nil && i.node.Kind != repo.KindNormal {
+		title += " [" + i.node.Kind.String() + "]"
+	}
+
+	// Ahead/behind badge, e.g. "↓3 ↑1"
+	if i.isGitRepo && i.node != nil && i.node.Tracking != nil {
+		if badge := i.node.Tracking.Badge(); badge != "" {
+			title += "  " + badge
+		}
+	}
+
+	// Don't show error message inline - it's shown at the bottom.
+	// Skip reasons are short and explain the distinct status.
+	if i.status == StatusSkipped && i.statusMsg != "" {
+		title += " (" + i.statusMsg + ")"
+	}
 
 	return style.Render(title)
 }
@@ -219,31 +310,31 @@ func InitialModel(initialState State) Model {
 	debug.Log("Initializing managers with CodebasesPath: %s", cfg.CodebasesPath)
 	manager := repo.NewManager(cfg.CodebasesPath)
 	git := repo.NewGit(cfg.CodebasesPath)
+	git.SetTimeouts(repo.Timeouts{
+		Clone:  time.Duration(cfg.Timeouts.Clone),
+		Pull:   time.Duration(cfg.Timeouts.Pull),
+		Status: time.Duration(cfg.Timeouts.Status),
+	})
 
-	// Scan for repositories
-	debug.Log("Scanning for repositories...")
-	repos, err := manager.List()
-	if err != nil {
-		debug.LogError(err, "scanning repositories")
-		return Model{err: fmt.Errorf("failed to scan repositories: %w", err)}
-	}
-	debug.Log("Found %d repositories", len(repos))
-
-	// Debug: Check if we found repositories
-	if len(repos) == 0 {
-		debug.Log("No repositories found in path: %s", cfg.CodebasesPath)
-		return Model{err: fmt.Errorf("no repositories found in: %s", cfg.CodebasesPath)}
+	// The tree from the last scan shows up at once and is checked against the
+	// disk in the background; without one it fills in as the scan finds
+	// repositories (see scanBatchMsg)
+	indexPath, _ := config.CachePath(repo.IndexFileName)
+	manager.OpenIndex(indexPath)
+	cached, haveCached := manager.Cached()
+	var scanCh chan scanBatchMsg
+	if haveCached {
+		debug.Log("Showing %d indexed entries, refreshing...", len(cached))
+		scanCh = refreshRepositories(manager)
+	} else {
+		debug.Log("Scanning for repositories...")
+		scanCh = scanRepositories(manager)
 	}
 
-	// Build tree structure from repositories
-	debug.Log("Building repository tree...")
-	tree := buildRepositoryTree(repos)
-	debug.Log("Built tree with %d root nodes", len(tree))
-
-	// Convert tree to flat list for display
-	debug.Log("Flattening tree for display...")
-	items := flattenTree(tree)
-	debug.Log("Created %d list items", len(items))
+	// Badges from the last fetch; without a cache directory they are only
+	// kept for this session
+	trackingPath, _ := config.CachePath(repo.TrackingFileName)
+	tracking := repo.LoadTrackingStore(trackingPath)
 
 	// Create list with proper dimensions
 	delegate := list.NewDefaultDelegate()
@@ -260,8 +351,8 @@ func InitialModel(initialState State) Model {
 	delegate.Styles.DimmedTitle = lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
 	delegate.Styles.FilterMatch = lipgloss.NewStyle().Foreground(lipgloss.Color("#4ec9b0"))
 
-	l := list.New(items, delegate, 80, 20) // Start with reasonable size like file browser
-	l.Title = "Your Repositories"
+	l := list.New(nil, delegate, 80, 20) // Start with reasonable size like file browser
+	l.Title = scanningTitle
 	l.SetShowHelp(false)
 	l.SetShowStatusBar(false)    // Hide status bar like file browser
 	l.SetShowTitle(true)         // Show title
@@ -271,11 +362,6 @@ func InitialModel(initialState State) Model {
 	// Set title styles to ensure visibility
 	l.Styles.Title = TitleStyle
 
-	// Ensure list starts at the top
-	if len(items) > 0 {
-		l.Select(0)
-	}
-
 	// Create spinner
 	s := spinner.New()
 	s.Spinner = spinner.Dot
@@ -284,16 +370,26 @@ func InitialModel(initialState State) Model {
 	// Create progress bar
 	p := progress.New(progress.WithDefaultGradient())
 
-	return Model{
-		state:    initialState,
-		config:   cfg,
-		list:     l,
-		spinner:  s,
-		progress: p,
-		selected: make(map[int]struct{}),
-		manager:  manager,
-		git:      git,
+	m := Model{
+		state:          initialState,
+		config:         cfg,
+		list:           l,
+		spinner:        s,
+		progress:       p,
+		selected:       make(map[int]struct{}),
+		manager:        manager,
+		git:            git,
+		tracking:       tracking,
+		scheduler:      jobs.NewScheduler(cfg.Jobs, cfg.HostJobs),
+		operationMutex: &sync.Mutex{},
+		progressCh:     make(chan progressMsg, progressBufferSize),
+		scanCh:         scanCh,
+	}
+	if haveCached {
+		m.list.SetItems(m.repositoryItems(cached))
+		m.list.Title = refreshingTitle
 	}
+	return m
 }
 
 func getListTitle(state State) string {
@@ -308,7 +404,13 @@ func getListTitle(state State) string {
 }
 
 // Messages
-type cloneFinishedMsg struct{ err error }
+type cloneFinishedMsg struct {
+	err       error
+	cancelled bool
+	cloned    bool   // The repository was cloned even if err is set
+	existing  string // Path of a checkout of the same remote that was reused instead
+	ref       string // Ref the reused checkout was switched to
+}
 type updateFinishedMsg struct {
 	repoName string
 	err      error
@@ -318,43 +420,239 @@ type removeFinishedMsg struct {
 	err      error
 }
 type batchOperationMsg struct {
+	repoName  string
+	success   bool
+	cancelled bool
+	skipped   bool
+	message   string
+}
+type progressMsg struct {
 	repoName string
-	success  bool
-	message  string
+	progress repo.Progress
 }
 type refreshListMsg struct{}
 type repositoryListMsg struct {
 	items []list.Item
 }
 
+// scanBatchMsg carries repositories found by the startup scan since the
+// previous batch. The last batch has done set, and err if the scan failed.
+type scanBatchMsg struct {
+	repos []repo.Repository
+	done  bool
+	err   error
+}
+
+// scanningTitle is the list title until the startup scan has finished
+const scanningTitle = "Your Repositories (scanning...)"
+
+// refreshingTitle is the list title while the indexed tree is checked
+// against the disk
+const refreshingTitle = "Your Repositories (refreshing...)"
+
+// scanBatchInterval is how often the startup scan hands what it found so far
+// to the UI; on fast disks the whole scan fits in the first batch
+const scanBatchInterval = 100 * time.Millisecond
+
+// progressBufferSize bounds the queue of progress updates waiting for the UI.
+// Updates beyond it are dropped; the next one supersedes them anyway.
+const progressBufferSize = 64
+
 // Commands
-func (m Model) cloneRepo(url string) tea.Cmd {
+
+// waitForProgress delivers the next git progress update to the update loop
+func waitForProgress(ch <-chan progressMsg) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// scanRepositories scans the codebases directory in the background and
+// delivers what it finds in batches, so the tree appears before the scan ends
+func scanRepositories(manager *repo.Manager) chan scanBatchMsg {
+	ch := make(chan scanBatchMsg, 1)
+	go func() {
+		var pending []repo.Repository
+		lastSent := time.Now()
+		err := manager.Scan(context.Background(), func(r repo.Repository) {
+			pending = append(pending, r)
+			if time.Since(lastSent) >= scanBatchInterval {
+				ch <- scanBatchMsg{repos: pending}
+				pending = nil
+				lastSent = time.Now()
+			}
+		})
+		ch <- scanBatchMsg{repos: pending, done: true, err: err}
+	}()
+	return ch
+}
+
+// refreshRepositories lists the repositories in the background, answering
+// from the index unless the disk changed since it was built, and delivers
+// them as a single batch that replaces the indexed tree shown meanwhile
+func refreshRepositories(manager *repo.Manager) chan scanBatchMsg {
+	ch := make(chan scanBatchMsg, 1)
+	go func() {
+		repos, err := manager.List()
+		ch <- scanBatchMsg{repos: repos, done: true, err: err}
+	}()
+	return ch
+}
+
+// waitForScan delivers the next batch of the startup scan to the update loop
+func waitForScan(ch <-chan scanBatchMsg) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
 	return func() tea.Msg {
-		if err := repo.ValidateURL(url); err != nil {
+		return <-ch
+	}
+}
+
+// startOperations creates a fresh cancellation scope for the operations about
+// to be dispatched. Commands capture m.opCtx when they are created.
+func (m *Model) startOperations() {
+	m.opCtx, m.cancelOps = context.WithCancel(context.Background())
+}
+
+// operationsRunning reports whether a clone or batch operation is in flight
+func (m Model) operationsRunning() bool {
+	return m.state == StateCloning || (m.totalOps > 0 && m.completedOps < m.totalOps)
+}
+
+// cancelOperations cancels all in-flight operations. Each one still reports
+// back, marked as cancelled, through its usual completion message.
+func (m *Model) cancelOperations() {
+	if m.cancelOps != nil {
+		m.cancelOps()
+	}
+	m.statusMsg = "Cancelling..."
+}
+
+// operationContext returns the context for newly dispatched commands
+func (m Model) operationContext() context.Context {
+	if m.opCtx == nil {
+		return context.Background()
+	}
+	return m.opCtx
+}
+
+// reportProgress returns a callback that forwards git progress for a repository
+// to the UI without ever blocking the git command
+func (m Model) reportProgress(repoName string) repo.ProgressFunc {
+	ch := m.progressCh
+	if ch == nil {
+		return nil
+	}
+	return func(p repo.Progress) {
+		select {
+		case ch <- progressMsg{repoName: repoName, progress: p}:
+		default:
+		}
+	}
+}
+
+func (m Model) cloneRepo(url string, opts repo.CloneOptions) tea.Cmd {
+	ctx := m.operationContext()
+	return func() tea.Msg {
+		remote, err := repo.ResolveURL(url)
+		if err != nil {
 			return cloneFinishedMsg{err: err}
 		}
 
-		clonePath := repo.GetClonePath(url)
+		clonePath := remote.ClonePath()
 		destination := m.manager.GetFullPath(clonePath)
+		// An existing clone of the same remote is reused, as on the command line
+		exists, err := m.git.ExistingCheckout(ctx, remote, clonePath, destination)
+		if err != nil {
+			return cloneFinishedMsg{err: err}
+		}
+		if exists {
+			// A requested ref is checked out in it, as on the command line
+			remote = m.git.ResolveLinkRefIn(ctx, destination, remote)
+			ref := remote.RequestedRef(opts)
+			if ref == "" {
+				return cloneFinishedMsg{existing: clonePath}
+			}
+			// ApplyConfig has already rejected unknown strategies
+			strategy, _ := repo.ParseUpdateStrategy(m.config.UpdateStrategy)
+			result := m.git.Checkout(ctx, destination, ref, strategy, m.reportProgress(clonePath))
+			if result.Cancelled() {
+				return cloneFinishedMsg{cancelled: true}
+			}
+			if err := result.CheckoutError(clonePath, ref); err != nil {
+				return cloneFinishedMsg{err: err}
+			}
+			return cloneFinishedMsg{existing: clonePath, ref: ref}
+		}
+		if remote, err = m.git.ResolveLinkRef(ctx, remote); err != nil {
+			return cloneFinishedMsg{err: err}
+		}
 
-		result := m.git.Clone(url, destination)
+		result := m.git.Clone(ctx, remote.CloneURL(), destination, remote.ResolveOptions(opts), m.reportProgress(clonePath))
+		if result.Cancelled() {
+			return cloneFinishedMsg{cancelled: true}
+		}
 		if !result.Success {
 			return cloneFinishedMsg{err: result.Error}
 		}
+		m.manager.RecordFetch(clonePath)
+		if err := m.manager.SaveIndex(); err != nil {
+			debug.LogError(err, "saving repository index")
+		}
+		if result.SubmoduleError != nil {
+			return cloneFinishedMsg{err: fmt.Errorf("cloned into %s, but %w", clonePath, result.SubmoduleError), cloned: true}
+		}
 
 		return cloneFinishedMsg{err: nil}
 	}
 }
 
+// updateRepo pulls a repository. The scheduler slot is reserved when the
+// command is created, so updates start in the order they were requested.
 func (m Model) updateRepo(repoName string) tea.Cmd {
+	ctx := m.operationContext()
+	ticket := m.scheduler.Enqueue(repo.RemoteHost(m.manager.GetFullPath(repoName)))
 	return func() tea.Msg {
+		if err := ticket.Wait(ctx); err != nil {
+			return batchOperationMsg{
+				repoName:  repoName,
+				cancelled: true,
+				message:   "Cancelled",
+			}
+		}
+		defer ticket.Done()
+
 		debug.Log("updateRepo command starting for: %s", repoName)
 		repoPath := m.manager.GetFullPath(repoName)
 		debug.Log("Full repo path: %s", repoPath)
 
 		// Mark as pending immediately
 		debug.Log("Starting git pull...")
-		result := m.git.Pull(repoPath)
+		// ApplyConfig has already rejected unknown strategies
+		strategy, _ := repo.ParseUpdateStrategy(m.config.UpdateStrategy)
+		result := m.git.Pull(ctx, repoPath, strategy, m.reportProgress(repoName))
+		if result.Success {
+			m.git.RecordTracking(ctx, m.tracking, repoName, repoPath)
+			m.manager.RecordFetch(repoName)
+		}
+		if result.Cancelled() {
+			return batchOperationMsg{
+				repoName:  repoName,
+				cancelled: true,
+				message:   "Cancelled",
+			}
+		}
+		if result.Skipped() {
+			return batchOperationMsg{
+				repoName: repoName,
+				skipped:  true,
+				message:  "skipped: " + result.SkipReason,
+			}
+		}
 
 		var message string
 		if !result.Success {
@@ -387,6 +685,14 @@ func (m Model) updateRepo(repoName string) tea.Cmd {
 			}
 		}
 
+		if result.SubmoduleError != nil {
+			return batchOperationMsg{
+				repoName: repoName,
+				success:  false,
+				message:  "Updated, but " + result.SubmoduleError.Error(),
+			}
+		}
+
 		return batchOperationMsg{
 			repoName: repoName,
 			success:  true,
@@ -395,10 +701,148 @@ func (m Model) updateRepo(repoName string) tea.Cmd {
 	}
 }
 
+// fetchRepo fetches a repository and records its ahead/behind counts. Like
+// updateRepo, its scheduler slot is reserved when the command is created.
+func (m Model) fetchRepo(repoName string) tea.Cmd {
+	ctx := m.operationContext()
+	ticket := m.scheduler.Enqueue(repo.RemoteHost(m.manager.GetFullPath(repoName)))
+	return func() tea.Msg {
+		if err := ticket.Wait(ctx); err != nil {
+			return batchOperationMsg{
+				repoName:  repoName,
+				cancelled: true,
+				message:   "Cancelled",
+			}
+		}
+		defer ticket.Done()
+
+		repoPath := m.manager.GetFullPath(repoName)
+		result := m.git.Fetch(ctx, repoPath, m.reportProgress(repoName))
+		if result.Cancelled() {
+			return batchOperationMsg{
+				repoName:  repoName,
+				cancelled: true,
+				message:   "Cancelled",
+			}
+		}
+		if !result.Success {
+			return batchOperationMsg{
+				repoName: repoName,
+				success:  false,
+				message:  result.Error.Error(),
+			}
+		}
+
+		m.manager.RecordFetch(repoName)
+		message := "No upstream"
+		if t, ok := m.git.RecordTracking(ctx, m.tracking, repoName, repoPath); ok {
+			message = "Up to date"
+			if badge := t.Badge(); badge != "" {
+				message = badge
+			}
+		}
+		return batchOperationMsg{
+			repoName: repoName,
+			success:  true,
+			message:  message,
+		}
+	}
+}
+
+// execRepo runs a saved command in a repository. Like updateRepo, its
+// scheduler slot is reserved when the command is created.
+func (m Model) execRepo(repoName, command string) tea.Cmd {
+	ctx := m.operationContext()
+	ticket := m.scheduler.Enqueue(repo.RemoteHost(m.manager.GetFullPath(repoName)))
+	return func() tea.Msg {
+		if err := ticket.Wait(ctx); err != nil {
+			return batchOperationMsg{
+				repoName:  repoName,
+				cancelled: true,
+				message:   "Cancelled",
+			}
+		}
+		defer ticket.Done()
+
+		var output bytes.Buffer
+		err := repo.Exec(ctx, m.manager.GetFullPath(repoName), []string{command}, &output)
+		if ctx.Err() != nil {
+			return batchOperationMsg{
+				repoName:  repoName,
+				cancelled: true,
+				message:   "Cancelled",
+			}
+		}
+
+		// The last line of output is usually the most telling one
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		lastLine := strings.TrimSpace(lines[len(lines)-1])
+		if err != nil {
+			message := err.Error()
+			if code := repo.ExitCode(err); code > 0 {
+				message = fmt.Sprintf("exit %d", code)
+			}
+			if lastLine != "" {
+				message += ": " + lastLine
+			}
+			return batchOperationMsg{
+				repoName: repoName,
+				success:  false,
+				message:  message,
+			}
+		}
+
+		if lastLine == "" {
+			lastLine = "Done"
+		}
+		return batchOperationMsg{
+			repoName: repoName,
+			success:  true,
+			message:  lastLine,
+		}
+	}
+}
+
+// applyTracking sets the stored ahead/behind counts on every repository node
+func applyTracking(nodes []*TreeNode, store *repo.TrackingStore) {
+	for _, node := range nodes {
+		if node.IsRepo {
+			node.Tracking = nil
+			if t, ok := store.Get(node.Path); ok {
+				node.Tracking = &t
+			}
+		}
+		applyTracking(node.Children, store)
+	}
+}
+
+// reposBelow returns the paths of node, if it is a repository, and of all
+// repositories below it
+func reposBelow(node *TreeNode) []string {
+	if node.IsRepo {
+		return []string{node.Path}
+	}
+	var paths []string
+	for _, child := range node.Children {
+		paths = append(paths, reposBelow(child)...)
+	}
+	return paths
+}
+
 func (m Model) removeRepo(repoName string) tea.Cmd {
+	ctx := m.operationContext()
 	return func() tea.Msg {
 		repoPath := m.manager.GetFullPath(repoName)
 
+		// Removal itself cannot be interrupted, but queued ones can be skipped
+		if ctx.Err() != nil {
+			return batchOperationMsg{
+				repoName:  repoName,
+				cancelled: true,
+				message:   "Cancelled",
+			}
+		}
+
 		if err := os.RemoveAll(repoPath); err != nil {
 			return batchOperationMsg{
 				repoName: repoName,
@@ -406,6 +850,8 @@ func (m Model) removeRepo(repoName string) tea.Cmd {
 				message:  err.Error(),
 			}
 		}
+		repo.Tags().Forget(repoName)
+		m.manager.RecordRemoval(repoName)
 
 		return batchOperationMsg{
 			repoName: repoName,
@@ -424,6 +870,12 @@ func buildRepositoryTree(repos []repo.Repository) []*TreeNode {
 	var rootNodes []*TreeNode
 	nodeMap := make(map[string]*TreeNode)
 
+	// With several roots, hosts are one level down
+	hostLevel := 0
+	if len(repo.RootNames()) > 0 {
+		hostLevel = 1
+	}
+
 	// Sort repositories by path for consistent ordering
 	sort.Slice(repos, func(i, j int) bool {
 		return repos[i].Name < repos[j].Name
@@ -463,7 +915,8 @@ func buildRepositoryTree(repos []repo.Repository) []*TreeNode {
 					Name:       part,
 					Path:       currentPath,
 					IsRepo:     isRepo,
-					IsExpanded: level == 0, // VCS providers expanded by default
+					Kind:       r.Kind,
+					IsExpanded: level <= hostLevel, // Roots and VCS providers expanded by default
 					Level:      level,
 					Parent:     parent,
 					Children:   []*TreeNode{},
@@ -492,6 +945,65 @@ func buildRepositoryTree(repos []repo.Repository) []*TreeNode {
 	return rootNodes
 }
 
+// untaggedNode names the tag view folder of repositories without a tag
+const untaggedNode = "(untagged)"
+
+// buildTagTree creates a tree with a folder per tag and configured group,
+// holding the repositories in it by full name. A repository in several
+// groups appears under each of them.
+func buildTagTree(repos []repo.Repository) []*TreeNode {
+	var names []string
+	kinds := make(map[string]repo.Kind)
+	for _, r := range repos {
+		if r.IsGitDir {
+			names = append(names, r.Name)
+			kinds[r.Name] = r.Kind
+		}
+	}
+	sort.Strings(names)
+
+	var rootNodes []*TreeNode
+	tagged := make(map[string]bool)
+	addRoot := func(name string, members []string) {
+		root := &TreeNode{Name: name, Path: name, IsExpanded: true, Children: []*TreeNode{}}
+		for _, member := range members {
+			root.Children = append(root.Children, &TreeNode{
+				Name:     filepath.ToSlash(member),
+				Path:     member,
+				IsRepo:   true,
+				Kind:     kinds[member],
+				Level:    1,
+				Parent:   root,
+				Children: []*TreeNode{},
+			})
+		}
+		rootNodes = append(rootNodes, root)
+	}
+
+	for _, group := range repo.GroupNames() {
+		// A group matching none of the repositories has nothing to show
+		members, err := repo.Selector{Patterns: []string{"@" + group}}.Select(names)
+		if err != nil || len(members) == 0 {
+			continue
+		}
+		for _, member := range members {
+			tagged[member] = true
+		}
+		addRoot("@"+group, members)
+	}
+
+	var untagged []string
+	for _, name := range names {
+		if !tagged[name] {
+			untagged = append(untagged, name)
+		}
+	}
+	if len(untagged) > 0 {
+		addRoot(untaggedNode, untagged)
+	}
+	return rootNodes
+}
+
 // sortTreeNodes recursively sorts tree nodes
 func sortTreeNodes(nodes []*TreeNode) {
 	sort.Slice(nodes, func(i, j int) bool {
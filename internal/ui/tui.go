@@ -2,7 +2,11 @@ package ui
 
 import (
 	"fmt"
+	"get-repo/config"
 	"get-repo/internal/debug"
+	"get-repo/internal/repo"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -13,7 +17,7 @@ import (
 )
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(textinput.Blink, m.spinner.Tick)
+	return tea.Batch(textinput.Blink, m.spinner.Tick, waitForProgress(m.progressCh), waitForScan(m.scanCh))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -56,9 +60,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Global key handling
 		debug.Log("Key pressed: %s in state %v", msg.String(), m.state)
 		if msg.String() == "ctrl+c" {
+			// First ctrl+c cancels running git operations, the next one quits
+			if m.operationsRunning() && m.opCtx != nil && m.opCtx.Err() == nil {
+				m.cancelOperations()
+				return m, nil
+			}
 			return m, tea.Quit
 		}
 
+		// Any key dismisses an error
+		if m.err != nil {
+			m.err = nil
+			return m, nil
+		}
+
 		// Handle state-specific keys
 		switch m.state {
 		case StateList:
@@ -71,14 +86,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleSetupKeys(msg)
 		case StateUpdateSelection, StateRemoveSelection:
 			return m.handleSelectionKeys(msg)
-		case StateBatchOperation:
-			// No key handling during batch operations
+		case StateCommandSelect:
+			return m.handleCommandSelectKeys(msg)
+		case StateSelectPattern:
+			return m.handleSelectPatternKeys(msg)
+		case StateCloning, StateBatchOperation:
+			// Only cancellation is available while operations run
+			if msg.String() == "esc" {
+				m.cancelOperations()
+			}
 			return m, nil
 		}
 
+	case progressMsg:
+		if m.state == StateCloning {
+			progress := msg.progress
+			m.cloneProgress = &progress
+		} else {
+			m.setNodeProgress(msg.repoName, msg.progress)
+		}
+		return m, waitForProgress(m.progressCh)
+
 	case cloneFinishedMsg:
-		if msg.err != nil {
+		m.cloneProgress = nil
+		if msg.cancelled {
+			m.statusMsg = "Clone cancelled"
+		} else if msg.err != nil {
 			m.err = msg.err
+			if msg.cloned {
+				m.state = StateList
+				return m, m.refreshRepositoryList()
+			}
+		} else if msg.existing != "" && msg.ref != "" {
+			m.statusMsg = "Already cloned at " + msg.existing + ", checked out " + msg.ref
+		} else if msg.existing != "" {
+			m.statusMsg = "Already cloned at " + msg.existing
 		} else {
 			m.statusMsg = "Clone completed successfully!"
 			// Refresh the repository list
@@ -90,13 +132,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.operationMutex.Lock()
 		m.completedOps++
 		m.operationResults = append(m.operationResults, OperationResult{
-			RepoName: msg.repoName,
-			Success:  msg.success,
-			Message:  msg.message,
+			RepoName:  msg.repoName,
+			Success:   msg.success,
+			Cancelled: msg.cancelled,
+			Skipped:   msg.skipped,
+			Message:   msg.message,
 		})
 
 		// Update tree node status
-		m.updateNodeStatus(msg.repoName, msg.success, msg.message)
+		status := StatusFailed
+		if msg.success {
+			status = StatusSuccess
+		} else if msg.cancelled {
+			status = StatusCancelled
+		} else if msg.skipped {
+			status = StatusSkipped
+		}
+		m.updateNodeStatus(msg.repoName, status, msg.message)
 
 		// Update progress
 		if m.totalOps > 0 {
@@ -109,6 +161,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.completedOps >= m.totalOps {
 			m.statusMsg = m.generateBatchSummary()
 
+			// Show and keep the ahead/behind counts fetches and updates recorded
+			m.applyTrackingToTree()
+			if err := m.tracking.Save(); err != nil {
+				debug.LogError(err, "saving tracking store")
+			}
+			if err := m.manager.SaveIndex(); err != nil {
+				debug.LogError(err, "saving repository index")
+			}
+			if err := repo.Tags().Save(); err != nil {
+				debug.LogError(err, "saving tags")
+			}
+
 			// Clear all selections after batch operation
 			items := m.list.Items()
 			newItems := make([]list.Item, len(items))
@@ -133,21 +197,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case repositoryListMsg:
-		// Update the list with new items
-		currentWidth, currentHeight := m.list.Width(), m.list.Height()
-		currentCursor := m.list.Cursor()
-
-		m.list.SetItems(msg.items)
-		m.list.SetSize(currentWidth, currentHeight)
-		m.list.Title = "Your Repositories"
+		m.setRepositoryItems(msg.items)
+		return m, nil
 
-		// Try to maintain cursor position if possible
-		if currentCursor < len(msg.items) {
-			m.list.Select(currentCursor)
-		} else if len(msg.items) > 0 {
-			m.list.Select(0)
+	case scanBatchMsg:
+		m.scanned = append(m.scanned, msg.repos...)
+		if !msg.done {
+			m.setRepositoryItems(m.repositoryItems(slices.Clone(m.scanned)))
+			m.list.Title = scanningTitle
+			return m, waitForScan(m.scanCh)
 		}
 
+		m.scanCh = nil
+		debug.Log("Startup scan found %d entries", len(m.scanned))
+		switch {
+		case msg.err != nil:
+			debug.LogError(msg.err, "scanning repositories")
+			m.err = fmt.Errorf("failed to scan repositories: %w", msg.err)
+		case len(m.scanned) == 0:
+			m.err = fmt.Errorf("no repositories found in: %s", m.config.CodebasesPath)
+		}
+		m.setRepositoryItems(m.repositoryItems(m.scanned))
+		m.scanned = nil
 		return m, nil
 
 	case error:
@@ -159,7 +230,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m.state {
 	case StateSetup:
 		// Setup wizard handles its own updates
-	case StateClone:
+	case StateClone, StateSelectPattern:
 		m.textInput, cmd = m.textInput.Update(msg)
 		cmds = append(cmds, cmd)
 	case StateCloning, StateUpdate:
@@ -192,12 +263,18 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	switch msg.String() {
 	case "q", "esc":
+		// Esc cancels running operations before it quits
+		if msg.String() == "esc" && m.operationsRunning() && m.opCtx != nil && m.opCtx.Err() == nil {
+			m.cancelOperations()
+			return m, nil
+		}
 		return m, tea.Quit
 	case "c":
 		m.state = StateClone
 		m.textInput = textinput.New()
 		m.textInput.Placeholder = "https://github.com/user/repo"
 		m.textInput.Focus()
+		m.clonePreset = 0
 		m.textInput.Width = 50
 		return m, textinput.Blink
 	case "u":
@@ -208,7 +285,8 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		for _, listItem := range items {
 			item := listItem.(Item)
-			if item.selected && item.isGitRepo {
+			// The tag view can show a repository more than once
+			if item.selected && item.isGitRepo && !slices.Contains(selectedRepos, item.node.Path) {
 				hasSelections = true
 				selectedRepos = append(selectedRepos, item.node.Path)
 			}
@@ -220,6 +298,7 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.totalOps = len(selectedRepos)
 			m.completedOps = 0
 			m.operationResults = nil // Clear previous results
+			m.startOperations()
 			// Process batch update
 
 			// Set pending status for all selected repositories
@@ -253,7 +332,8 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Initialize batch operation tracking for single operation
 		m.totalOps = 1
 		m.completedOps = 0
-		m.operationResults = nil           // Clear previous results
+		m.operationResults = nil // Clear previous results
+		m.startOperations()
 		m.list.Title = "Your Repositories" // Ensure title is set
 
 		// Stay in list state
@@ -262,6 +342,44 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.spinner.Tick, // Start spinner animation
 			m.updateRepo(repoPath),
 		)
+	case "f":
+		// Fetch the selected repositories, or those at or below the cursor
+		targets := m.targetRepos()
+		if len(targets) == 0 {
+			return m, nil
+		}
+
+		m.totalOps = len(targets)
+		m.completedOps = 0
+		m.operationResults = nil // Clear previous results
+		m.startOperations()
+		m.statusMsg = fmt.Sprintf("Fetching %d repositories...", len(targets))
+
+		var cmds []tea.Cmd
+		for _, repoPath := range targets {
+			m.setNodePending(repoPath)
+			cmds = append(cmds, m.fetchRepo(repoPath))
+		}
+		cmds = append(cmds, m.spinner.Tick)
+		return m, tea.Batch(cmds...)
+	case "x":
+		// Run a saved command in the selected repositories, or in those at
+		// or below the cursor
+		targets := m.targetRepos()
+		if len(targets) == 0 {
+			return m, nil
+		}
+		switch len(m.config.Commands) {
+		case 0:
+			m.statusMsg = "No saved commands; add \"commands\" to the config file"
+			return m, nil
+		case 1:
+			return m.runSavedCommand(m.config.Commands[0], targets)
+		}
+		m.execTargets = targets
+		m.commandIndex = 0
+		m.state = StateCommandSelect
+		return m, nil
 	case "r":
 		// First check if we have pre-selected items from the main list
 		items := m.list.Items()
@@ -270,7 +388,7 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		for _, listItem := range items {
 			item := listItem.(Item)
-			if item.selected && item.isGitRepo {
+			if item.selected && item.isGitRepo && !slices.Contains(selectedRepos, item.node.Path) {
 				hasSelections = true
 				selectedRepos = append(selectedRepos, item.node.Path)
 			}
@@ -292,6 +410,26 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Confirm single removal
 		m.state = StateRemoveConfirm
 		return m, nil
+	case "s":
+		m.state = StateSelectPattern
+		m.textInput = textinput.New()
+		m.textInput.Placeholder = "github.com/myorg/* !*-archive --host gitlab.com"
+		m.textInput.Focus()
+		m.textInput.Width = 50
+		return m, textinput.Blink
+	case "t":
+		// Pivot the tree between directories and tags; rebuilding it would
+		// lose the status of running operations
+		if m.operationsRunning() {
+			return m, nil
+		}
+		m.tagView = !m.tagView
+		if m.tagView {
+			m.statusMsg = "Grouped by tag (press 't' for directories)"
+		} else {
+			m.statusMsg = ""
+		}
+		return m, m.refreshRepositoryList()
 	case "/":
 		// Enable filtering
 		m.list.SetFilteringEnabled(true)
@@ -383,26 +521,43 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.list, cmd = m.list.Update(msg)
 		return m, cmd
 	}
-	return m, nil
 }
 
 func (m Model) handleCloneKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
-		url := m.textInput.Value()
-		if url == "" {
+		spec := strings.TrimSpace(m.textInput.Value())
+		if spec == "" {
 			m.err = fmt.Errorf("please enter a URL")
 			return m, nil
 		}
+		// Options may follow the URL, as in a clone file line
+		url, opts, err := repo.ParseCloneSpec(spec)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		opts = clonePresets[m.clonePreset].options.Merge(opts)
+
 		m.state = StateCloning
 		m.statusMsg = fmt.Sprintf("Cloning %s...", url)
-		return m, m.cloneRepo(url)
+		m.startOperations()
+		return m, m.cloneRepo(url, opts)
+	case "tab":
+		m.clonePreset = (m.clonePreset + 1) % len(clonePresets)
+		return m, nil
+	case "shift+tab":
+		m.clonePreset = (m.clonePreset + len(clonePresets) - 1) % len(clonePresets)
+		return m, nil
 	case "esc":
 		m.state = StateList
 		m.err = nil
 		return m, nil
 	}
-	return m, nil
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
 }
 
 func (m Model) handleRemoveConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -415,6 +570,7 @@ func (m Model) handleRemoveConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.totalOps = len(m.batchRemoveRepos)
 			m.completedOps = 0
 			m.operationResults = nil
+			m.startOperations()
 
 			// Set pending status for all selected repositories
 			for _, repoPath := range m.batchRemoveRepos {
@@ -447,6 +603,93 @@ func (m Model) handleRemoveConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+func (m Model) handleSelectPatternKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		sel, err := repo.ParseSelector(m.textInput.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		if sel.IsEmpty() {
+			m.err = fmt.Errorf("please enter a pattern")
+			return m, nil
+		}
+		count, err := m.selectMatching(sel)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.state = StateList
+		m.statusMsg = fmt.Sprintf("%d repositories selected (press 'u' to update, 'f' to fetch, 'x' to run, 'r' to remove)", count)
+		return m, nil
+	case "esc":
+		m.state = StateList
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) handleCommandSelectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.commandIndex > 0 {
+			m.commandIndex--
+		}
+	case "down", "j":
+		if m.commandIndex < len(m.config.Commands)-1 {
+			m.commandIndex++
+		}
+	case "enter":
+		targets := m.execTargets
+		m.execTargets = nil
+		m.state = StateList
+		return m.runSavedCommand(m.config.Commands[m.commandIndex], targets)
+	case "esc", "q":
+		m.execTargets = nil
+		m.state = StateList
+	}
+	return m, nil
+}
+
+// runSavedCommand starts a saved command in each target repository, tracked
+// like a batch update
+func (m Model) runSavedCommand(command config.SavedCommand, targets []string) (tea.Model, tea.Cmd) {
+	m.totalOps = len(targets)
+	m.completedOps = 0
+	m.operationResults = nil // Clear previous results
+	m.startOperations()
+	m.statusMsg = fmt.Sprintf("Running %q in %d repositories...", command.Name, len(targets))
+
+	var cmds []tea.Cmd
+	for _, repoPath := range targets {
+		m.setNodePending(repoPath)
+		cmds = append(cmds, m.execRepo(repoPath, command.Command))
+	}
+	cmds = append(cmds, m.spinner.Tick)
+	return m, tea.Batch(cmds...)
+}
+
+// targetRepos returns the selected repositories, or if none are selected
+// those at or below the cursor
+func (m Model) targetRepos() []string {
+	var targets []string
+	for _, listItem := range m.list.Items() {
+		if item := listItem.(Item); item.selected && item.isGitRepo && !slices.Contains(targets, item.node.Path) {
+			targets = append(targets, item.node.Path)
+		}
+	}
+	if len(targets) == 0 {
+		if selectedItem, ok := m.list.SelectedItem().(Item); ok && selectedItem.node != nil {
+			targets = reposBelow(selectedItem.node)
+		}
+	}
+	return targets
+}
+
 func (m Model) handleSetupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -463,7 +706,8 @@ func (m Model) handleSetupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 
 			// Reinitialize with list state
-			return InitialModel(StateList), nil
+			model := InitialModel(StateList)
+			return model, tea.Batch(waitForProgress(model.progressCh), waitForScan(model.scanCh))
 		}
 	}
 
@@ -483,15 +727,23 @@ func (m Model) handleSelectionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "enter":
-		// Process selected items
+		// Process selected items in list order
 		var selectedRepos []string
 		items := m.list.Items()
 
+		indices := make([]int, 0, len(m.selected))
 		for idx := range m.selected {
+			indices = append(indices, idx)
+		}
+		sort.Ints(indices)
+
+		for _, idx := range indices {
 			if idx < len(items) {
 				item := items[idx].(Item)
 				// Use the full path from the node for git repos
-				selectedRepos = append(selectedRepos, item.node.Path)
+				if !slices.Contains(selectedRepos, item.node.Path) {
+					selectedRepos = append(selectedRepos, item.node.Path)
+				}
 			}
 		}
 
@@ -500,11 +752,15 @@ func (m Model) handleSelectionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Remember the operation before leaving the selection state
+		isUpdate := m.state == StateUpdateSelection
+
 		// Stay in list state but track operations
 		m.state = StateList
 		m.totalOps = len(selectedRepos)
 		m.completedOps = 0
 		m.operationResults = nil
+		m.startOperations()
 
 		// Set pending status for all selected repositories
 		for _, repoName := range selectedRepos {
@@ -514,7 +770,7 @@ func (m Model) handleSelectionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Create commands for each repo
 		var cmds []tea.Cmd
 		for _, repoName := range selectedRepos {
-			if m.state == StateUpdateSelection {
+			if isUpdate {
 				cmds = append(cmds, m.updateRepo(repoName))
 			} else {
 				cmds = append(cmds, m.removeRepo(repoName))
@@ -547,19 +803,34 @@ func (m Model) handleSelectionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 func (m Model) generateBatchSummary() string {
 	successCount := 0
+	cancelCount := 0
+	skipCount := 0
 	for _, result := range m.operationResults {
 		if result.Success {
 			successCount++
+		} else if result.Cancelled {
+			cancelCount++
+		} else if result.Skipped {
+			skipCount++
 		}
 	}
 
-	failCount := len(m.operationResults) - successCount
+	failCount := len(m.operationResults) - successCount - cancelCount - skipCount
+
+	skipped := ""
+	if skipCount > 0 {
+		skipped = fmt.Sprintf(", %d skipped", skipCount)
+	}
+
+	if cancelCount > 0 {
+		return fmt.Sprintf("Cancelled: %d succeeded, %d failed%s, %d cancelled", successCount, failCount, skipped, cancelCount)
+	}
 
-	if failCount == 0 {
+	if failCount == 0 && skipCount == 0 {
 		return fmt.Sprintf("✓ All %d operations completed successfully!", successCount)
 	}
 
-	return fmt.Sprintf("Completed: %d succeeded, %d failed", successCount, failCount)
+	return fmt.Sprintf("Completed: %d succeeded, %d failed%s", successCount, failCount, skipped)
 }
 
 func (m Model) View() string {
@@ -574,7 +845,9 @@ func (m Model) View() string {
 		s = m.setupWizard.View()
 	case StateClone:
 		s = m.renderClone()
-	case StateCloning, StateUpdate:
+	case StateCloning:
+		s = m.renderCloning()
+	case StateUpdate:
 		s = m.renderSpinner()
 	case StateRemoveConfirm:
 		s = m.renderRemoveConfirm()
@@ -584,6 +857,10 @@ func (m Model) View() string {
 		s = m.renderSelection()
 	case StateBatchOperation:
 		s = m.renderBatchOperation()
+	case StateCommandSelect:
+		s = m.renderCommandSelect()
+	case StateSelectPattern:
+		s = m.renderSelectPattern()
 	default:
 		s = "Unknown state"
 	}
@@ -595,10 +872,39 @@ func (m Model) View() string {
 
 func (m Model) renderClone() string {
 	return fmt.Sprintf(
-		"\n%s\n\nEnter the repository URL to clone.\n\n%s\n\n%s",
+		"\n%s\n\nEnter the repository URL to clone, optionally at a ref (gh:user/repo@v1.2, #branch)\nand with options (--depth 1, --filter blob:none, --single-branch, --sparse dir1,dir2).\n\n%s\n\nMode: %s\n\n%s",
 		TitleStyle.Render("Clone Repository"),
 		m.textInput.View(),
-		HelpStyle.Render("Enter to clone • Esc to cancel"),
+		SelectedItemStyle.Render(clonePresets[m.clonePreset].name),
+		HelpStyle.Render("Enter to clone • Tab to change mode • Esc to cancel"),
+	)
+}
+
+func (m Model) renderSelectPattern() string {
+	return fmt.Sprintf(
+		"\n%s\n\nSelect repositories by name, glob (github.com/myorg/*) or regular expression (re:^gitlab),\n!pattern to exclude, --host and --owner to narrow down. Matches are added to the selection.\n\n%s\n\n%s",
+		TitleStyle.Render("Select by Pattern"),
+		m.textInput.View(),
+		HelpStyle.Render("Enter to select • Esc to cancel"),
+	)
+}
+
+func (m Model) renderCommandSelect() string {
+	var lines []string
+	for i, command := range m.config.Commands {
+		line := fmt.Sprintf("%s  %s", command.Name, HelpStyle.Render(command.Command))
+		if i == m.commandIndex {
+			lines = append(lines, SelectedItemStyle.Render("▶ ")+line)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return fmt.Sprintf(
+		"\n%s\n\nRun in %d repositories:\n\n%s\n\n%s",
+		TitleStyle.Render("Run Saved Command"),
+		len(m.execTargets),
+		strings.Join(lines, "\n"),
+		HelpStyle.Render("↑/↓ choose • Enter to run • Esc to cancel"),
 	)
 }
 
@@ -606,6 +912,18 @@ func (m Model) renderSpinner() string {
 	return fmt.Sprintf("\n\n   %s %s\n\n", m.spinner.View(), m.statusMsg)
 }
 
+func (m Model) renderCloning() string {
+	help := HelpStyle.Render("Esc to cancel")
+	if m.cloneProgress == nil {
+		return m.renderSpinner() + "   " + help
+	}
+	return fmt.Sprintf("\n\n   %s %s\n\n   %s\n   %s\n\n   %s",
+		m.spinner.View(), m.statusMsg,
+		m.progress.ViewAs(m.cloneProgress.Overall()),
+		HelpStyle.Render(m.cloneProgress.String()),
+		help)
+}
+
 func (m Model) renderRemoveConfirm() string {
 	// Check if this is a batch removal
 	if len(m.batchRemoveRepos) > 0 {
@@ -640,7 +958,7 @@ func (m Model) renderList() string {
 	if len(m.operationResults) > 0 && m.state == StateList {
 		var recentErrors []string
 		for _, result := range m.operationResults {
-			if !result.Success {
+			if !result.Success && !result.Cancelled && !result.Skipped {
 				errorMsg := result.Message
 				if len(errorMsg) > 60 {
 					errorMsg = errorMsg[:57] + "..."
@@ -741,6 +1059,8 @@ func (m Model) renderBatchOperation() string {
 	// Split results into succeeded and failed for better organization
 	var succeeded []string
 	var failed []string
+	var cancelled []string
+	var skipped []string
 	var pending []string
 
 	// Track which repos have been processed
@@ -749,6 +1069,10 @@ func (m Model) renderBatchOperation() string {
 		processedRepos[result.RepoName] = true
 		if result.Success {
 			succeeded = append(succeeded, fmt.Sprintf("  ✓ %s", result.RepoName))
+		} else if result.Cancelled {
+			cancelled = append(cancelled, fmt.Sprintf("  ⊘ %s", result.RepoName))
+		} else if result.Skipped {
+			skipped = append(skipped, fmt.Sprintf("  ↷ %s (%s)", result.RepoName, result.Message))
 		} else {
 			// Format error message more clearly
 			errorMsg := result.Message
@@ -765,7 +1089,12 @@ func (m Model) renderBatchOperation() string {
 	for _, item := range items {
 		if i := item.(Item); i.node != nil && i.node.Status == StatusPending {
 			if !processedRepos[i.node.Path] {
-				pending = append(pending, fmt.Sprintf("  ••• %s", i.node.Name))
+				if p := i.node.Progress; p != nil {
+					pending = append(pending, fmt.Sprintf("  %s %s", m.progress.ViewAs(p.Overall()), i.node.Name))
+					pending = append(pending, HelpStyle.Render(fmt.Sprintf("    └─ %s", p)))
+				} else {
+					pending = append(pending, fmt.Sprintf("  ••• %s", i.node.Name))
+				}
 			}
 		}
 	}
@@ -795,13 +1124,33 @@ func (m Model) renderBatchOperation() string {
 	if len(failed) > 0 {
 		sections = append(sections, ErrorStyle.Render("Failed:"))
 		sections = append(sections, ErrorStyle.Render(strings.Join(failed, "\n")))
+		sections = append(sections, "")
+	}
+
+	if len(skipped) > 0 {
+		sections = append(sections, PendingStyle.Render("Skipped:"))
+		sections = append(sections, PendingStyle.Render(strings.Join(skipped, "\n")))
+		sections = append(sections, "")
+	}
+
+	if len(cancelled) > 0 {
+		sections = append(sections, HelpStyle.Render("Cancelled:"))
+		sections = append(sections, HelpStyle.Render(strings.Join(cancelled, "\n")))
+		sections = append(sections, "")
+	}
+
+	if m.operationsRunning() {
+		sections = append(sections, HelpStyle.Render("Esc/ctrl+c cancel"))
 	}
 
 	return "\n" + strings.Join(sections, "\n")
 }
 
 func (m Model) getListHelp() string {
-	return HelpStyle.Render("↑/↓ navigate • ←/→ collapse/expand • Space select • a all • n none • c clone • u update • r remove • q quit")
+	if m.operationsRunning() {
+		return HelpStyle.Render("↑/↓ navigate • ←/→ collapse/expand • esc/ctrl+c cancel operations")
+	}
+	return HelpStyle.Render("↑/↓ navigate • ←/→ collapse/expand • Space select • a all • n none • c clone • u update • s select • t tags • f fetch • x run • r remove • q quit")
 }
 
 func (m Model) getSelectionHelp() string {
@@ -884,7 +1233,7 @@ func (m Model) handleExpandCollapse(expand bool) (Model, tea.Cmd) {
 }
 
 // updateNodeStatus updates the status of a tree node based on operation result
-func (m *Model) updateNodeStatus(repoName string, success bool, message string) {
+func (m *Model) updateNodeStatus(repoName string, status OperationStatus, message string) {
 	items := m.list.Items()
 
 	// Find and update the specific node without rebuilding the tree
@@ -894,13 +1243,10 @@ func (m *Model) updateNodeStatus(repoName string, success bool, message string)
 		// Check if this item matches the repository
 		if item.isGitRepo && item.node.Path == repoName {
 			// Update status directly on the node (this will be reflected in the display)
-			if success {
-				item.node.Status = StatusSuccess
-			} else {
-				item.node.Status = StatusFailed
-			}
+			// The tag view can show a repository more than once
+			item.node.Status = status
 			item.node.StatusMsg = message
-			break // Found the item, no need to continue
+			item.node.Progress = nil
 		}
 	}
 
@@ -920,7 +1266,7 @@ func (m *Model) setNodePending(repoName string) {
 		if item.isGitRepo && item.node.Path == repoName {
 			item.node.Status = StatusPending
 			item.node.StatusMsg = "Operation in progress..."
-			break // Found the item, no need to continue
+			item.node.Progress = nil
 		}
 	}
 
@@ -928,33 +1274,111 @@ func (m *Model) setNodePending(repoName string) {
 	m.refreshTreeDisplay()
 }
 
-// refreshTreeDisplay rebuilds the flat list from tree nodes while preserving expansion states
-func (m *Model) refreshTreeDisplay() {
-	items := m.list.Items()
+// setNodeProgress records the latest git progress on a pending repository node.
+// Items render straight from the node, so no tree rebuild is needed.
+func (m *Model) setNodeProgress(repoName string, progress repo.Progress) {
+	for _, listItem := range m.list.Items() {
+		item := listItem.(Item)
+		if item.isGitRepo && item.node.Path == repoName && item.node.Status == StatusPending {
+			item.node.Progress = &progress
+		}
+	}
+}
 
-	// Collect all root nodes from current items
-	rootMap := make(map[string]*TreeNode)
+// treeRoots returns the root nodes of the tree, in display order
+func (m Model) treeRoots() []*TreeNode {
+	seen := make(map[*TreeNode]bool)
+	var roots []*TreeNode
+	for _, listItem := range m.list.Items() {
+		item := listItem.(Item)
+		if item.node == nil {
+			continue
+		}
+		root := item.node
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		if !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
 
-	for _, listItem := range items {
+// applyTrackingToTree refreshes the ahead/behind badges of every repository
+// node, including those in collapsed folders
+func (m *Model) applyTrackingToTree() {
+	applyTracking(m.treeRoots(), m.tracking)
+}
+
+// selectMatching adds the repositories the selector picks to the selection,
+// including those in collapsed folders, which are expanded to show them.
+// It returns how many repositories are selected in total.
+func (m *Model) selectMatching(sel repo.Selector) (int, error) {
+	roots := m.treeRoots()
+	var paths []string
+	for _, root := range roots {
+		paths = append(paths, reposBelow(root)...)
+	}
+	names, err := sel.Select(paths)
+	if err != nil {
+		return 0, err
+	}
+	if len(names) == 0 {
+		return 0, fmt.Errorf("no repositories match")
+	}
+
+	matched := make(map[string]bool)
+	for _, name := range names {
+		matched[name] = true
+	}
+	for _, listItem := range m.list.Items() {
+		if item := listItem.(Item); item.selected && item.isGitRepo {
+			matched[item.node.Path] = true
+		}
+	}
+	expandMatching(roots, matched)
+
+	newItems := flattenTree(roots)
+	for i, listItem := range newItems {
 		item := listItem.(Item)
-		if item.node != nil {
-			// Find the root of this node
-			root := item.node
-			for root.Parent != nil {
-				root = root.Parent
-			}
-			rootMap[root.Name] = root
+		if item.isGitRepo && matched[item.node.Path] {
+			item.selected = true
+			newItems[i] = item
 		}
 	}
+	count := len(matched)
 
-	// Convert map to slice
-	var rootNodes []*TreeNode
-	for _, root := range rootMap {
-		rootNodes = append(rootNodes, root)
+	// Preserve list state when updating items
+	currentWidth, currentHeight := m.list.Width(), m.list.Height()
+	currentCursor := m.list.Cursor()
+	m.list.SetItems(newItems)
+	m.list.SetSize(currentWidth, currentHeight)
+	m.list.Select(currentCursor)
+	return count, nil
+}
+
+// expandMatching expands every folder containing a repository in matched and
+// reports whether any was found below nodes
+func expandMatching(nodes []*TreeNode, matched map[string]bool) bool {
+	found := false
+	for _, node := range nodes {
+		if node.IsRepo && matched[node.Path] {
+			found = true
+		}
+		if expandMatching(node.Children, matched) {
+			node.IsExpanded = true
+			found = true
+		}
 	}
+	return found
+}
 
+// refreshTreeDisplay rebuilds the flat list from tree nodes while preserving expansion states
+func (m *Model) refreshTreeDisplay() {
 	// Flatten tree with current expansion states preserved
-	newItems := flattenTree(rootNodes)
+	newItems := flattenTree(m.treeRoots())
 
 	// Preserve list state
 	currentWidth, currentHeight := m.list.Width(), m.list.Height()
@@ -983,13 +1407,37 @@ func (m Model) refreshRepositoryList() tea.Cmd {
 		if err != nil {
 			return error(fmt.Errorf("failed to scan repositories: %w", err))
 		}
+		return repositoryListMsg{items: m.repositoryItems(repos)}
+	}
+}
+
+// repositoryItems builds the list items for repos, grouped by directory or,
+// in the tag view, by tag
+func (m Model) repositoryItems(repos []repo.Repository) []list.Item {
+	tree := buildRepositoryTree(repos)
+	if m.tagView {
+		tree = buildTagTree(repos)
+	}
+	applyTracking(tree, m.tracking)
+
+	// Convert tree to flat list for display
+	return flattenTree(tree)
+}
 
-		// Build tree structure from repositories
-		tree := buildRepositoryTree(repos)
+// setRepositoryItems replaces the list items, keeping the size and, where
+// possible, the cursor position
+func (m *Model) setRepositoryItems(items []list.Item) {
+	currentWidth, currentHeight := m.list.Width(), m.list.Height()
+	currentCursor := m.list.Cursor()
 
-		// Convert tree to flat list for display
-		items := flattenTree(tree)
+	m.list.SetItems(items)
+	m.list.SetSize(currentWidth, currentHeight)
+	m.list.Title = "Your Repositories"
 
-		return repositoryListMsg{items: items}
+	// Try to maintain cursor position if possible
+	if currentCursor < len(items) {
+		m.list.Select(currentCursor)
+	} else if len(items) > 0 {
+		m.list.Select(0)
 	}
 }